@@ -0,0 +1,101 @@
+package diffnest
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ANSI escape sequences used for colored output.
+const (
+	ansiReset  = "\x1b[0m"
+	ansiBold   = "\x1b[1m"
+	ansiDim    = "\x1b[2m"
+	ansiInvert = "\x1b[7m"
+	ansiRevert = "\x1b[27m"
+	ansiRed    = "\x1b[31m"
+	ansiGreen  = "\x1b[32m"
+	ansiYellow = "\x1b[33m"
+	ansiCyan   = "\x1b[36m"
+)
+
+// colorWriter colors the lines of unified diff output by their prefix.
+// Incomplete lines are buffered until the next newline or Flush.
+type colorWriter struct {
+	w   io.Writer
+	buf []byte
+}
+
+func (c *colorWriter) Write(p []byte) (int, error) {
+	c.buf = append(c.buf, p...)
+
+	for {
+		i := bytes.IndexByte(c.buf, '\n')
+		if i < 0 {
+			break
+		}
+		if err := c.writeLine(string(c.buf[:i]), "\n"); err != nil {
+			return 0, err
+		}
+		c.buf = c.buf[i+1:]
+	}
+
+	return len(p), nil
+}
+
+// Flush writes a trailing incomplete line.
+func (c *colorWriter) Flush() error {
+	if len(c.buf) == 0 {
+		return nil
+	}
+
+	line := string(c.buf)
+	c.buf = nil
+
+	return c.writeLine(line, "")
+}
+
+func (c *colorWriter) writeLine(line, newline string) error {
+	color := lineColor(line)
+	if color == "" {
+		_, err := io.WriteString(c.w, line+newline)
+		if err != nil {
+			return fmt.Errorf("write line: %w", err)
+		}
+
+		return nil
+	}
+
+	if _, err := io.WriteString(c.w, color+line+ansiReset+newline); err != nil {
+		return fmt.Errorf("write colored line: %w", err)
+	}
+
+	return nil
+}
+
+// lineColor returns the color of a unified diff line: red for deletions, green for additions,
+// yellow for moves, cyan for comment changes, bold for keys leading to changes and dim for context.
+func lineColor(line string) string {
+	switch {
+	case line == "":
+		return ""
+	case line == "---":
+		return ansiBold
+	case strings.HasPrefix(line, "-"):
+		return ansiRed
+	case strings.HasPrefix(line, "+"):
+		return ansiGreen
+	case strings.HasPrefix(line, "~"):
+		return ansiYellow
+	case strings.HasPrefix(line, "#"):
+		return ansiCyan
+	case strings.TrimSpace(line) == "...":
+		return ansiDim
+	case strings.HasSuffix(line, ":") || strings.TrimSpace(line) == "-":
+		// Object keys and array markers form the path to the changes below them
+		return ansiBold
+	default:
+		return ansiDim
+	}
+}
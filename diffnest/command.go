@@ -5,13 +5,37 @@ import (
 	"flag"
 	"fmt"
 	"io"
+	"math"
+	"os"
+	"path"
+	"strconv"
+	"strings"
 )
 
-const arrayStrategyIndex = "index"
+const (
+	colorAuto   = "auto"
+	colorAlways = "always"
+	colorNever  = "never"
+
+	formatStat = "stat"
+
+	arrayStrategyIndex   = "index"
+	arrayStrategyKey     = "key"
+	arrayStrategyOrdered = "ordered"
+)
 
 var (
 	ErrInvalidArgs         = errors.New("expected 2 files")
 	ErrIncompatibleOptions = errors.New("--show-all and -C options are incompatible: context lines are only meaningful when showing only differences")
+	ErrInvalidArrayKey     = errors.New("invalid array key, expected path=key[,key...]")
+	ErrInvalidApplyArgs    = errors.New("expected a document and a patch file")
+	ErrInvalidColor        = errors.New("invalid color mode, expected auto, always, or never")
+	ErrInvalidPathStyle    = errors.New("invalid path style, expected dot or jsonpath")
+	ErrStatWithFormat      = errors.New("-stat only works with unified, side-by-side, markdown and paths formats")
+	ErrDirectoryFormat     = errors.New("directories can only be compared with unified, side-by-side, markdown, paths and stat formats")
+	ErrDirectoryMismatch   = errors.New("cannot compare a directory with a file")
+	ErrInvalidTolerance    = errors.New("invalid tolerance, expected [path=]number or [path=]number%")
+	ErrArrayKeyStrategy    = errors.New("-array-key only works with -array-strategy key")
 )
 
 // Version information (set via ldflags during build).
@@ -27,14 +51,39 @@ type Command struct {
 	IgnoreEmpty      bool
 	IgnoreKeyCase    bool
 	IgnoreValueCase  bool
+	SortKeys         bool
+	CompareComments  bool
+	DetectMoves      bool
 	ArrayStrategy    string
+	ArrayKeys        arrayKeyRules
+	IgnorePaths      pathPatterns
+	OnlyPaths        pathPatterns
+	Tolerances       toleranceRules
+	Normalizers      normalizerRules
+	CoerceTypes      bool
+	ShowTypeChanges  bool
+	ShowDelta        bool
 	OutputFormat     string
+	PatchTest        bool
+	Color            string
 	Format1          string
 	Format2          string
 	Verbose          bool
+	Quiet            bool
 	Help             bool
 	ShowVersion      bool
 	ContextLines     int
+	Width            int
+	PathStyle        string
+	Stat             bool
+	StatTop          int
+	Include          globList
+	Exclude          globList
+
+	// Terminal tells whether output goes to a terminal, for -color=auto
+	Terminal bool
+	// TerminalWidth is the width of the output terminal, used when -width is not set
+	TerminalWidth int
 
 	// Arguments
 	File1 string
@@ -59,14 +108,37 @@ func NewCommand(name string, errorHandling flag.ErrorHandling) *Command {
 	cmd.flags.BoolVar(&cmd.IgnoreEmpty, "ignore-empty", false, "Ignore empty fields")
 	cmd.flags.BoolVar(&cmd.IgnoreKeyCase, "ignore-key-case", false, "Ignore case differences in object keys")
 	cmd.flags.BoolVar(&cmd.IgnoreValueCase, "ignore-value-case", false, "Ignore case differences in string values")
-	cmd.flags.StringVar(&cmd.ArrayStrategy, "array-strategy", "value", "Array comparison strategy: 'index' or 'value'")
-	cmd.flags.StringVar(&cmd.OutputFormat, "format", "unified", "Output format: 'unified' or 'json-patch'")
-	cmd.flags.StringVar(&cmd.Format1, "format1", "", "Format for first file: 'json', 'yaml', or auto-detect from filename")
-	cmd.flags.StringVar(&cmd.Format2, "format2", "", "Format for second file: 'json', 'yaml', or auto-detect from filename")
+	cmd.flags.BoolVar(&cmd.SortKeys, "sort-keys", false, "Order object keys alphabetically instead of by source order")
+	cmd.flags.BoolVar(&cmd.CompareComments, "compare-comments", false, "Report changes in YAML comments")
+	cmd.flags.BoolVar(&cmd.DetectMoves, "detect-moves", false, "Report moved array elements and renamed object keys; with -array-strategy index, arrays are compared as with ordered")
+	cmd.flags.StringVar(&cmd.ArrayStrategy, "array-strategy", "value", "Array comparison strategy: 'index', 'value', 'key', or 'ordered'")
+	cmd.flags.Var(&cmd.ArrayKeys, "array-key", "Identity keys for array elements, e.g. 'spec.containers=name'; implies -array-strategy key (repeatable)")
+	cmd.flags.Var(&cmd.IgnorePaths, "ignore-path", "Path pattern of values to ignore, e.g. 'metadata.resourceVersion' or '**.lastTransitionTime' (repeatable)")
+	cmd.flags.Var(&cmd.OnlyPaths, "only-path", "Path pattern of values to compare, ignoring everything else, e.g. 'spec.template' (repeatable)")
+	cmd.flags.Var(&cmd.Tolerances, "tolerance", "Numeric tolerance, absolute like '1e-9' or relative like '0.1%', optionally for a path pattern like 'metrics.**=0.5%' (repeatable)")
+	cmd.flags.Var(&cmd.Normalizers, "normalize", "Compare values by meaning: quantity, duration, timestamp, bool or number, optionally for a path pattern like 'spec.**.resources=quantity' (repeatable, comma-separated)")
+	cmd.flags.BoolVar(&cmd.CoerceTypes, "coerce-types", false, "Compare strings holding numbers, booleans or null with values of those types, so \"8080\" equals 8080")
+	cmd.flags.BoolVar(&cmd.ShowTypeChanges, "show-type-changes", false, "Like -coerce-types, but show values equal only after coercion as type changes, which are not differences")
+	cmd.flags.BoolVar(&cmd.ShowDelta, "show-delta", false, "Show the difference next to modified numbers in unified format")
+	cmd.flags.Var(patternFile{&cmd.IgnorePaths}, "ignore-path-file", "File of -ignore-path patterns, one per line (repeatable)")
+	cmd.flags.Var(patternFile{&cmd.OnlyPaths}, "only-path-file", "File of -only-path patterns, one per line (repeatable)")
+	cmd.flags.StringVar(&cmd.OutputFormat, "format", "unified", "Output format: 'unified', 'side-by-side', 'html', 'markdown', 'paths', 'stat', 'json', 'json-patch', or 'merge-patch'")
+	cmd.flags.BoolVar(&cmd.Stat, "stat", false, "Print a summary of the changes before the diff")
+	cmd.flags.IntVar(&cmd.StatTop, "stat-top", defaultStatTop, "Number of most changed subtrees in the summary (0 for none)")
+	cmd.flags.StringVar(&cmd.PathStyle, "path-style", PathStyleDot, "Path style for paths format: 'dot' (spec.items[0].name) or 'jsonpath' ($.spec.items[0].name)")
+	cmd.flags.IntVar(&cmd.Width, "width", 0, "Output width for side-by-side format (default: terminal width)")
+	cmd.flags.StringVar(&cmd.Color, "color", colorAuto, "Color output: 'auto', 'always', or 'never' (auto colors terminals unless NO_COLOR is set)")
+	cmd.flags.BoolVar(&cmd.PatchTest, "patch-test", false, "Add 'test' operations before removals, replacements and moves in JSON Patch output")
+	cmd.flags.StringVar(&cmd.Format1, "format1", "", "Format for first file: 'json', 'yaml', 'toml', or auto-detect from filename")
+	cmd.flags.StringVar(&cmd.Format2, "format2", "", "Format for second file: 'json', 'yaml', 'toml', or auto-detect from filename")
+	cmd.flags.Var(&cmd.Include, "include", "Glob of files to compare in directories, e.g. '*.yaml' (repeatable, default: JSON, YAML and TOML files)")
+	cmd.flags.Var(&cmd.Exclude, "exclude", "Glob of files and directories to skip in directories, e.g. 'secrets' (repeatable)")
 	cmd.flags.BoolVar(&cmd.Verbose, "v", false, "Verbose output")
+	cmd.flags.BoolVar(&cmd.Quiet, "q", false, "Print nothing, only report differences through the exit code")
+	cmd.flags.BoolVar(&cmd.Quiet, "quiet", false, "Same as -q")
 	cmd.flags.BoolVar(&cmd.Help, "h", false, "Show help")
 	cmd.flags.BoolVar(&cmd.ShowVersion, "version", false, "Show version information")
-	cmd.flags.IntVar(&cmd.ContextLines, "C", 3, "Number of context lines to show (only for unified format)")
+	cmd.flags.IntVar(&cmd.ContextLines, "C", 3, "Number of context lines to show (unified, side-by-side and markdown formats)")
 
 	return cmd
 }
@@ -105,12 +177,54 @@ func (c *Command) Parse(args []string) error {
 		return ErrIncompatibleOptions
 	}
 
+	switch c.Color {
+	case colorAuto, colorAlways, colorNever:
+	default:
+		return fmt.Errorf("%w: %s", ErrInvalidColor, c.Color)
+	}
+
+	if c.Stat {
+		switch c.OutputFormat {
+		case "unified", "side-by-side", "markdown", "paths", formatStat:
+		default:
+			return fmt.Errorf("%w, got %s", ErrStatWithFormat, c.OutputFormat)
+		}
+	}
+
+	switch c.PathStyle {
+	case PathStyleDot, PathStyleJSONPath:
+	default:
+		return fmt.Errorf("%w: %s", ErrInvalidPathStyle, c.PathStyle)
+	}
+
+	// -array-key implies the key strategy, but contradicts any other
+	if len(c.ArrayKeys) > 0 && c.ArrayStrategy != arrayStrategyKey {
+		if c.flagSet("array-strategy") {
+			return fmt.Errorf("%w, got %s", ErrArrayKeyStrategy, c.ArrayStrategy)
+		}
+		c.ArrayStrategy = arrayStrategyKey
+	}
+
 	return nil
 }
 
+// flagSet reports whether the flag with the given name was given on the command line.
+func (c *Command) flagSet(name string) bool {
+	set := false
+	c.flags.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+
+	return set
+}
+
 // Usage prints usage information.
 func (c *Command) Usage(w io.Writer) {
 	fmt.Fprintf(w, "Usage: diffnest [options] <file1> <file2>\n")
+	fmt.Fprintf(w, "       diffnest [options] <dir1> <dir2>\n")
+	fmt.Fprintf(w, "       diffnest apply [options] <document> <patch>\n")
 	fmt.Fprintf(w, "\nOptions:\n")
 	c.flags.SetOutput(w)
 	c.flags.PrintDefaults()
@@ -120,6 +234,28 @@ func (c *Command) Usage(w io.Writer) {
 	fmt.Fprintf(w, "  diffnest file1.json file2.yaml  # Compare different formats\n")
 	fmt.Fprintf(w, "  cat file1.json | diffnest - file2.json\n")
 	fmt.Fprintf(w, "  diffnest --format1 json - file2.yaml  # Force JSON format for stdin\n")
+	fmt.Fprintf(w, "  diffnest -exclude secrets envs/staging envs/prod  # Compare directory trees\n")
+}
+
+// GetDirOptions returns the options of a directory comparison.
+func (c *Command) GetDirOptions() DirOptions {
+	return DirOptions{
+		Include: c.Include,
+		Exclude: c.Exclude,
+		Format1: c.Format1,
+		Format2: c.Format2,
+	}
+}
+
+// ValidateDirectories checks that the options can be used to compare directories,
+// where the output of each file pair is preceded by a header.
+func (c *Command) ValidateDirectories() error {
+	switch c.OutputFormat {
+	case "unified", "side-by-side", "markdown", "paths", formatStat:
+		return nil
+	default:
+		return fmt.Errorf("%w, got %s", ErrDirectoryFormat, c.OutputFormat)
+	}
 }
 
 // GetDiffOptions returns DiffOptions based on command flags.
@@ -129,11 +265,29 @@ func (c *Command) GetDiffOptions() DiffOptions {
 		IgnoreEmptyFields: c.IgnoreEmpty,
 		IgnoreKeyCase:     c.IgnoreKeyCase,
 		IgnoreValueCase:   c.IgnoreValueCase,
+		SortKeys:          c.SortKeys,
+		CompareComments:   c.CompareComments,
+		DetectMoves:       c.DetectMoves,
+		IgnorePaths:       c.IgnorePaths,
+		OnlyPaths:         c.OnlyPaths,
+		Tolerance:         c.Tolerances.global,
+		PathTolerances:    c.Tolerances.paths,
+		Normalizers:       c.Normalizers.global,
+		PathNormalizers:   c.Normalizers.paths,
+		CoerceTypes:       c.CoerceTypes || c.ShowTypeChanges,
+		ReportTypeChanges: c.ShowTypeChanges,
 	}
 
-	if c.ArrayStrategy == arrayStrategyIndex {
+	switch c.ArrayStrategy {
+	case arrayStrategyIndex:
 		opts.ArrayDiffStrategy = ArrayStrategyIndex
-	} else {
+	case arrayStrategyOrdered:
+		opts.ArrayDiffStrategy = ArrayStrategyOrdered
+	case arrayStrategyKey:
+		opts.ArrayDiffStrategy = ArrayStrategyKey
+		// User rules come first so they win over built-in rules for the same path
+		opts.ArrayKeys = append(append([]ArrayKeyRule{}, c.ArrayKeys...), KubernetesArrayKeys()...)
+	default:
 		opts.ArrayDiffStrategy = ArrayStrategyValue
 	}
 
@@ -141,19 +295,79 @@ func (c *Command) GetDiffOptions() DiffOptions {
 }
 
 // GetFormatter returns the appropriate formatter based on command flags.
+// It returns nil with -q, as nothing is printed.
 func (c *Command) GetFormatter() Formatter {
+	if c.Quiet {
+		return nil
+	}
+	if c.OutputFormat == formatStat {
+		return &StatFormatter{Top: c.StatTop}
+	}
+
+	formatter := c.outputFormatter()
+	if c.Stat {
+		return &StatFormatter{Top: c.StatTop, Next: formatter}
+	}
+
+	return formatter
+}
+
+// outputFormatter returns the formatter of the -format flag.
+func (c *Command) outputFormatter() Formatter {
 	switch c.OutputFormat {
+	case "json":
+		return &JSONFormatter{}
+	case "paths":
+		return &PathsFormatter{ShowOnlyDiff: !c.ShowAll, PathStyle: c.PathStyle}
 	case "json-patch":
-		return &JSONPatchFormatter{}
+		return &JSONPatchFormatter{Test: c.PatchTest}
+	case "merge-patch":
+		return &MergePatchFormatter{}
+	case "html":
+		return &HTMLFormatter{SortKeys: c.SortKeys}
+	case "markdown":
+		return &MarkdownFormatter{
+			ShowOnlyDiff: !c.ShowAll,
+			ContextLines: c.ContextLines,
+			SortKeys:     c.SortKeys,
+		}
+	case "side-by-side":
+		width := c.Width
+		if width <= 0 {
+			width = c.TerminalWidth
+		}
+
+		return &SideBySideFormatter{
+			ShowOnlyDiff: !c.ShowAll,
+			ContextLines: c.ContextLines,
+			Width:        width,
+			SortKeys:     c.SortKeys,
+		}
 	default:
 		return &UnifiedFormatter{
 			ShowOnlyDiff: !c.ShowAll,
 			Verbose:      c.Verbose,
 			ContextLines: c.ContextLines,
+			SortKeys:     c.SortKeys,
+			Color:        c.ColorEnabled(),
+			ShowDelta:    c.ShowDelta,
 		}
 	}
 }
 
+// ColorEnabled reports whether output should be colored.
+// In auto mode, output is colored on terminals unless the NO_COLOR environment variable is set.
+func (c *Command) ColorEnabled() bool {
+	switch c.Color {
+	case colorAlways:
+		return true
+	case colorNever:
+		return false
+	}
+
+	return c.Terminal && os.Getenv("NO_COLOR") == ""
+}
+
 // GetFormat1 returns the format for file1, auto-detecting if necessary.
 func (c *Command) GetFormat1() string {
 	if c.Format1 != "" {
@@ -177,3 +391,285 @@ func (c *Command) GetFormat2() string {
 
 	return DetectFormatFromFilename(c.File2)
 }
+
+// arrayKeyRules collects repeated -array-key flags.
+type arrayKeyRules []ArrayKeyRule
+
+func (r *arrayKeyRules) String() string {
+	rules := make([]string, 0, len(*r))
+	for _, rule := range *r {
+		rules = append(rules, rule.Path+"="+strings.Join(rule.Keys, ","))
+	}
+
+	return strings.Join(rules, " ")
+}
+
+func (r *arrayKeyRules) Set(value string) error {
+	path, keys, ok := strings.Cut(value, "=")
+	if !ok || path == "" || keys == "" {
+		return fmt.Errorf("%w: %q", ErrInvalidArrayKey, value)
+	}
+
+	rule := ArrayKeyRule{Path: path}
+	for _, key := range strings.Split(keys, ",") {
+		if key == "" {
+			return fmt.Errorf("%w: %q", ErrInvalidArrayKey, value)
+		}
+		rule.Keys = append(rule.Keys, key)
+	}
+	*r = append(*r, rule)
+
+	return nil
+}
+
+// pathPatterns collects repeated path pattern flags.
+type pathPatterns []PathPattern
+
+func (p *pathPatterns) String() string {
+	patterns := make([]string, 0, len(*p))
+	for _, pattern := range *p {
+		patterns = append(patterns, pattern.String())
+	}
+
+	return strings.Join(patterns, " ")
+}
+
+func (p *pathPatterns) Set(value string) error {
+	pattern, err := ParsePathPattern(value)
+	if err != nil {
+		return err
+	}
+	*p = append(*p, pattern)
+
+	return nil
+}
+
+// toleranceRules collects repeated -tolerance flags. Flags for the same path
+// pattern, or without one, combine an absolute and a relative tolerance.
+type toleranceRules struct {
+	global Tolerance
+	paths  []PathTolerance
+	values []string
+}
+
+func (r *toleranceRules) String() string {
+	return strings.Join(r.values, " ")
+}
+
+func (r *toleranceRules) Set(value string) error {
+	pattern, number := "", value
+	if i := strings.LastIndex(value, "="); i >= 0 {
+		pattern, number = value[:i], value[i+1:]
+	}
+
+	relative := strings.HasSuffix(number, "%")
+	amount, err := strconv.ParseFloat(strings.TrimSuffix(number, "%"), 64)
+	if err != nil || amount < 0 || math.IsInf(amount, 0) || math.IsNaN(amount) {
+		return fmt.Errorf("%w: %q", ErrInvalidTolerance, value)
+	}
+
+	tolerance := &r.global
+	if pattern != "" {
+		tolerance = nil
+		for i := range r.paths {
+			if r.paths[i].Path.String() == pattern {
+				tolerance = &r.paths[i].Tolerance
+			}
+		}
+		if tolerance == nil {
+			path, err := ParsePathPattern(pattern)
+			if err != nil {
+				return err
+			}
+			r.paths = append(r.paths, PathTolerance{Path: path})
+			tolerance = &r.paths[len(r.paths)-1].Tolerance
+		}
+	}
+
+	if relative {
+		tolerance.Relative = amount / 100
+	} else {
+		tolerance.Absolute = amount
+	}
+	r.values = append(r.values, value)
+
+	return nil
+}
+
+// normalizerRules collects repeated -normalize flags.
+type normalizerRules struct {
+	global []Normalizer
+	paths  []PathNormalizer
+	values []string
+}
+
+func (r *normalizerRules) String() string {
+	return strings.Join(r.values, " ")
+}
+
+func (r *normalizerRules) Set(value string) error {
+	pattern, names := "", value
+	if i := strings.LastIndex(value, "="); i >= 0 {
+		pattern, names = value[:i], value[i+1:]
+	}
+
+	var path PathPattern
+	if pattern != "" {
+		var err error
+		if path, err = ParsePathPattern(pattern); err != nil {
+			return err
+		}
+	}
+
+	for _, name := range strings.Split(names, ",") {
+		normalizer, err := NormalizerByName(strings.TrimSpace(name))
+		if err != nil {
+			return err
+		}
+		if pattern == "" {
+			r.global = append(r.global, normalizer)
+		} else {
+			r.paths = append(r.paths, PathNormalizer{Path: path, Normalizer: normalizer})
+		}
+	}
+	r.values = append(r.values, value)
+
+	return nil
+}
+
+// patternFile adds the path patterns of a file to patterns.
+type patternFile struct {
+	patterns *pathPatterns
+}
+
+func (f patternFile) String() string {
+	return ""
+}
+
+func (f patternFile) Set(filename string) error {
+	file, err := os.Open(filename)
+	if err != nil {
+		return fmt.Errorf("open path patterns: %w", err)
+	}
+	defer file.Close()
+
+	patterns, err := ReadPathPatterns(file)
+	if err != nil {
+		return fmt.Errorf("%s: %w", filename, err)
+	}
+	*f.patterns = append(*f.patterns, patterns...)
+
+	return nil
+}
+
+// globList collects repeated glob flags.
+type globList []string
+
+func (g *globList) String() string {
+	return strings.Join(*g, " ")
+}
+
+func (g *globList) Set(value string) error {
+	if _, err := path.Match(value, ""); err != nil {
+		return fmt.Errorf("%w: %q", ErrInvalidGlob, value)
+	}
+	*g = append(*g, value)
+
+	return nil
+}
+
+// ApplyCommand represents the configuration of the apply subcommand.
+type ApplyCommand struct {
+	// Flags
+	Format      string
+	PatchFormat string
+	PatchType   string
+	Output      string
+	Help        bool
+
+	// Arguments
+	File      string
+	PatchFile string
+
+	flags *flag.FlagSet
+}
+
+// NewApplyCommand creates a new ApplyCommand instance.
+func NewApplyCommand(name string, errorHandling flag.ErrorHandling) *ApplyCommand {
+	cmd := &ApplyCommand{
+		flags: flag.NewFlagSet(name, errorHandling),
+	}
+
+	cmd.flags.StringVar(&cmd.Format, "format", "", "Format of the document: 'json', 'yaml', 'toml', or auto-detect from filename")
+	cmd.flags.StringVar(&cmd.PatchFormat, "patch-format", "", "Format of the patch file: 'json', 'yaml', or auto-detect from filename")
+	cmd.flags.StringVar(&cmd.PatchType, "type", "", "Patch type: 'json-patch' or 'merge-patch' (default: json-patch for arrays, merge-patch for objects)")
+	cmd.flags.StringVar(&cmd.Output, "o", "", "Write the patched document to this file instead of stdout")
+	cmd.flags.BoolVar(&cmd.Help, "h", false, "Show help")
+
+	return cmd
+}
+
+// SetOutput sets the output destination for error messages.
+func (c *ApplyCommand) SetOutput(w io.Writer) {
+	c.flags.SetOutput(w)
+}
+
+// Parse parses command line arguments.
+func (c *ApplyCommand) Parse(args []string) error {
+	if err := c.flags.Parse(args); err != nil {
+		return fmt.Errorf("parse flags: %w", err)
+	}
+
+	if c.flags.NArg() != 2 && !c.Help {
+		return fmt.Errorf("%w, got %d", ErrInvalidApplyArgs, c.flags.NArg())
+	}
+
+	if c.flags.NArg() >= 2 {
+		c.File = c.flags.Arg(0)
+		c.PatchFile = c.flags.Arg(1)
+	}
+
+	switch c.PatchType {
+	case "", PatchTypeJSONPatch, PatchTypeMergePatch:
+	default:
+		return fmt.Errorf("%w: %s", ErrUnsupportedPatchType, c.PatchType)
+	}
+
+	return nil
+}
+
+// Usage prints usage information.
+func (c *ApplyCommand) Usage(w io.Writer) {
+	fmt.Fprintf(w, "Usage: diffnest apply [options] <document> <patch>\n")
+	fmt.Fprintf(w, "\nApplies a JSON Patch (RFC 6902) or JSON Merge Patch (RFC 7386) and writes the document in its original format.\n")
+	fmt.Fprintf(w, "\nOptions:\n")
+	c.flags.SetOutput(w)
+	c.flags.PrintDefaults()
+	fmt.Fprintf(w, "\nExample:\n")
+	fmt.Fprintf(w, "  diffnest -format json-patch old.yaml new.yaml > changes.json\n")
+	fmt.Fprintf(w, "  diffnest apply -o old.yaml old.yaml changes.json\n")
+}
+
+// GetFormat returns the format for the document, auto-detecting if necessary.
+func (c *ApplyCommand) GetFormat() string {
+	if c.Format != "" {
+		return c.Format
+	}
+	if c.File == "-" {
+		return FormatYAML
+	}
+
+	return DetectFormatFromFilename(c.File)
+}
+
+// GetPatchFormat returns the format for the patch file, auto-detecting if necessary.
+func (c *ApplyCommand) GetPatchFormat() string {
+	if c.PatchFormat != "" {
+		return c.PatchFormat
+	}
+	if c.PatchFile == "-" {
+		return FormatYAML
+	}
+
+	return DetectFormatFromFilename(c.PatchFile)
+}
@@ -3,6 +3,10 @@ package diffnest
 import (
 	"bytes"
 	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 )
@@ -80,6 +84,210 @@ func TestCommand_Parse(t *testing.T) {
 				}
 			},
 		},
+		{
+			name:    "Array keys",
+			args:    []string{"-array-strategy", "key", "-array-key", "spec.containers=name", "-array-key", "ports=containerPort,protocol", "f1", "f2"},
+			wantErr: false,
+			check: func(t *testing.T, cmd *Command) {
+				t.Helper()
+				want := arrayKeyRules{
+					{Path: "spec.containers", Keys: []string{"name"}},
+					{Path: "ports", Keys: []string{"containerPort", "protocol"}},
+				}
+				if !reflect.DeepEqual(cmd.ArrayKeys, want) {
+					t.Errorf("ArrayKeys = %v, want %v", cmd.ArrayKeys, want)
+				}
+			},
+		},
+		{
+			name:    "Array key implies the key strategy",
+			args:    []string{"-array-key", "items=id", "f1", "f2"},
+			wantErr: false,
+			check: func(t *testing.T, cmd *Command) {
+				t.Helper()
+				if got := cmd.GetDiffOptions().ArrayDiffStrategy; got != ArrayStrategyKey {
+					t.Errorf("ArrayDiffStrategy = %v, want ArrayStrategyKey", got)
+				}
+			},
+		},
+		{
+			name:    "Array key with another strategy",
+			args:    []string{"-array-strategy", "index", "-array-key", "items=id", "f1", "f2"},
+			wantErr: true,
+		},
+		{
+			name:    "Invalid array key",
+			args:    []string{"-array-key", "containers", "f1", "f2"},
+			wantErr: true,
+		},
+		{
+			name:    "Patch test",
+			args:    []string{"-format", "json-patch", "-patch-test", "f1", "f2"},
+			wantErr: false,
+			check: func(t *testing.T, cmd *Command) {
+				t.Helper()
+				formatter, ok := cmd.GetFormatter().(*JSONPatchFormatter)
+				if !ok || !formatter.Test {
+					t.Errorf("GetFormatter() = %#v, want JSONPatchFormatter with Test", cmd.GetFormatter())
+				}
+			},
+		},
+		{
+			name:    "Invalid color",
+			args:    []string{"-color", "sometimes", "f1", "f2"},
+			wantErr: true,
+		},
+		{
+			name:    "JSONPath path style",
+			args:    []string{"-format", "paths", "-path-style", "jsonpath", "f1", "f2"},
+			wantErr: false,
+			check: func(t *testing.T, cmd *Command) {
+				t.Helper()
+				formatter, ok := cmd.GetFormatter().(*PathsFormatter)
+				if !ok || formatter.PathStyle != PathStyleJSONPath {
+					t.Errorf("GetFormatter() = %#v, want PathsFormatter with jsonpath style", cmd.GetFormatter())
+				}
+			},
+		},
+		{
+			name:    "Stat header",
+			args:    []string{"-stat", "-stat-top", "2", "-format", "paths", "f1", "f2"},
+			wantErr: false,
+			check: func(t *testing.T, cmd *Command) {
+				t.Helper()
+				formatter, ok := cmd.GetFormatter().(*StatFormatter)
+				if !ok || formatter.Top != 2 {
+					t.Fatalf("GetFormatter() = %#v, want StatFormatter with Top 2", cmd.GetFormatter())
+				}
+				if _, ok := formatter.Next.(*PathsFormatter); !ok {
+					t.Errorf("StatFormatter.Next = %#v, want PathsFormatter", formatter.Next)
+				}
+			},
+		},
+		{
+			name:    "Stat alone",
+			args:    []string{"-format", "stat", "f1", "f2"},
+			wantErr: false,
+			check: func(t *testing.T, cmd *Command) {
+				t.Helper()
+				formatter, ok := cmd.GetFormatter().(*StatFormatter)
+				if !ok || formatter.Next != nil || formatter.Top != defaultStatTop {
+					t.Errorf("GetFormatter() = %#v, want StatFormatter without Next", cmd.GetFormatter())
+				}
+			},
+		},
+		{
+			name:    "Directory globs",
+			args:    []string{"-include", "*.yaml", "-exclude", "secrets", "-exclude", "tmp/*", "d1", "d2"},
+			wantErr: false,
+			check: func(t *testing.T, cmd *Command) {
+				t.Helper()
+				opts := cmd.GetDirOptions()
+				if !reflect.DeepEqual(opts.Include, []string{"*.yaml"}) || !reflect.DeepEqual(opts.Exclude, []string{"secrets", "tmp/*"}) {
+					t.Errorf("GetDirOptions() = %+v", opts)
+				}
+			},
+		},
+		{
+			name:    "Invalid glob",
+			args:    []string{"-include", "[a", "d1", "d2"},
+			wantErr: true,
+		},
+		{
+			name:    "Path patterns",
+			args:    []string{"-ignore-path", "status", "-ignore-path", "**.lastTransitionTime", "-only-path", "spec.template", "f1", "f2"},
+			wantErr: false,
+			check: func(t *testing.T, cmd *Command) {
+				t.Helper()
+				opts := cmd.GetDiffOptions()
+				if len(opts.IgnorePaths) != 2 || opts.IgnorePaths[1].String() != "**.lastTransitionTime" {
+					t.Errorf("IgnorePaths = %v", opts.IgnorePaths)
+				}
+				if len(opts.OnlyPaths) != 1 || opts.OnlyPaths[0].String() != "spec.template" {
+					t.Errorf("OnlyPaths = %v", opts.OnlyPaths)
+				}
+			},
+		},
+		{
+			name:    "Invalid path pattern",
+			args:    []string{"-ignore-path", "items[", "f1", "f2"},
+			wantErr: true,
+		},
+		{
+			name:    "Tolerances",
+			args:    []string{"-tolerance", "1e-9", "-tolerance", "0.1%", "-tolerance", "items[name=a].x=2%", "-tolerance", "items[name=a].x=0.5", "f1", "f2"},
+			wantErr: false,
+			check: func(t *testing.T, cmd *Command) {
+				t.Helper()
+				opts := cmd.GetDiffOptions()
+				if opts.Tolerance != (Tolerance{Absolute: 1e-9, Relative: 0.001}) {
+					t.Errorf("Tolerance = %+v", opts.Tolerance)
+				}
+				if len(opts.PathTolerances) != 1 || opts.PathTolerances[0].Path.String() != "items[name=a].x" ||
+					opts.PathTolerances[0].Tolerance != (Tolerance{Absolute: 0.5, Relative: 0.02}) {
+					t.Errorf("PathTolerances = %+v", opts.PathTolerances)
+				}
+			},
+		},
+		{
+			name:    "Invalid tolerance",
+			args:    []string{"-tolerance", "-1", "f1", "f2"},
+			wantErr: true,
+		},
+		{
+			name:    "Normalizers",
+			args:    []string{"-normalize", "bool,number", "-normalize", "**.resources=quantity", "f1", "f2"},
+			wantErr: false,
+			check: func(t *testing.T, cmd *Command) {
+				t.Helper()
+				opts := cmd.GetDiffOptions()
+				if len(opts.Normalizers) != 2 || opts.Normalizers[0] != (BoolNormalizer{}) || opts.Normalizers[1] != (NumberNormalizer{}) {
+					t.Errorf("Normalizers = %+v", opts.Normalizers)
+				}
+				if len(opts.PathNormalizers) != 1 || opts.PathNormalizers[0].Path.String() != "**.resources" ||
+					opts.PathNormalizers[0].Normalizer != (QuantityNormalizer{}) {
+					t.Errorf("PathNormalizers = %+v", opts.PathNormalizers)
+				}
+			},
+		},
+		{
+			name:    "Show type changes",
+			args:    []string{"-show-type-changes", "f1", "f2"},
+			wantErr: false,
+			check: func(t *testing.T, cmd *Command) {
+				t.Helper()
+				opts := cmd.GetDiffOptions()
+				if !opts.CoerceTypes || !opts.ReportTypeChanges {
+					t.Errorf("CoerceTypes = %v, ReportTypeChanges = %v, want both", opts.CoerceTypes, opts.ReportTypeChanges)
+				}
+			},
+		},
+		{
+			name:    "Unknown normalizer",
+			args:    []string{"-normalize", "semver", "f1", "f2"},
+			wantErr: true,
+		},
+		{
+			name:    "Quiet long form",
+			args:    []string{"--quiet", "f1", "f2"},
+			wantErr: false,
+			check: func(t *testing.T, cmd *Command) {
+				t.Helper()
+				if !cmd.Quiet {
+					t.Error("Quiet = false, want true")
+				}
+			},
+		},
+		{
+			name:    "Stat with machine-readable format",
+			args:    []string{"-stat", "-format", "json-patch", "f1", "f2"},
+			wantErr: true,
+		},
+		{
+			name:    "Invalid path style",
+			args:    []string{"-path-style", "xpath", "f1", "f2"},
+			wantErr: true,
+		},
 		{
 			name:    "Format flags",
 			args:    []string{"-format1", "json", "-format2", "yaml", "f1", "f2"},
@@ -184,6 +392,70 @@ func TestCommand_GetDiffOptions(t *testing.T) {
 				}
 			},
 		},
+		{
+			name: "Sort keys",
+			setup: func(cmd *Command) {
+				cmd.SortKeys = true
+			},
+			check: func(t *testing.T, opts DiffOptions) {
+				t.Helper()
+				if !opts.SortKeys {
+					t.Error("SortKeys should be true")
+				}
+			},
+		},
+		{
+			name: "Compare comments",
+			setup: func(cmd *Command) {
+				cmd.CompareComments = true
+			},
+			check: func(t *testing.T, opts DiffOptions) {
+				t.Helper()
+				if !opts.CompareComments {
+					t.Error("CompareComments should be true")
+				}
+			},
+		},
+		{
+			name: "Detect moves",
+			setup: func(cmd *Command) {
+				cmd.DetectMoves = true
+			},
+			check: func(t *testing.T, opts DiffOptions) {
+				t.Helper()
+				if !opts.DetectMoves {
+					t.Error("DetectMoves should be true")
+				}
+			},
+		},
+		{
+			name: "Key array strategy",
+			setup: func(cmd *Command) {
+				cmd.ArrayStrategy = "key"
+				cmd.ArrayKeys = arrayKeyRules{{Path: "items", Keys: []string{"id"}}}
+			},
+			check: func(t *testing.T, opts DiffOptions) {
+				t.Helper()
+				if opts.ArrayDiffStrategy != ArrayStrategyKey {
+					t.Error("ArrayDiffStrategy should be Key")
+				}
+				if len(opts.ArrayKeys) != len(KubernetesArrayKeys())+1 || opts.ArrayKeys[0].Path != "items" {
+					t.Errorf("ArrayKeys should start with user rules followed by Kubernetes rules, got %v", opts.ArrayKeys)
+				}
+			},
+		},
+		{
+			name: "Ordered array strategy",
+			setup: func(cmd *Command) {
+				cmd.ArrayStrategy = "ordered"
+			},
+			check: func(t *testing.T, opts DiffOptions) {
+				t.Helper()
+				if opts.ArrayDiffStrategy != ArrayStrategyOrdered {
+					t.Error("ArrayDiffStrategy should be Ordered")
+				}
+			},
+		},
 		{
 			name: "Index array strategy",
 			setup: func(cmd *Command) {
@@ -226,6 +498,36 @@ func TestCommand_GetFormatter(t *testing.T) {
 			format:   "json-patch",
 			wantType: "*diffnest.JSONPatchFormatter",
 		},
+		{
+			name:     "Merge patch formatter",
+			format:   "merge-patch",
+			wantType: "*diffnest.MergePatchFormatter",
+		},
+		{
+			name:     "Side-by-side formatter",
+			format:   "side-by-side",
+			wantType: "*diffnest.SideBySideFormatter",
+		},
+		{
+			name:     "HTML formatter",
+			format:   "html",
+			wantType: "*diffnest.HTMLFormatter",
+		},
+		{
+			name:     "Markdown formatter",
+			format:   "markdown",
+			wantType: "*diffnest.MarkdownFormatter",
+		},
+		{
+			name:     "JSON formatter",
+			format:   "json",
+			wantType: "*diffnest.JSONFormatter",
+		},
+		{
+			name:     "Paths formatter",
+			format:   "paths",
+			wantType: "*diffnest.PathsFormatter",
+		},
 		{
 			name:     "Unified with show all",
 			format:   "unified",
@@ -264,6 +566,30 @@ func TestCommand_GetFormatter(t *testing.T) {
 				if tt.wantType != "*diffnest.JSONPatchFormatter" {
 					t.Errorf("Got JSONPatchFormatter, want %s", tt.wantType)
 				}
+			case *MergePatchFormatter:
+				if tt.wantType != "*diffnest.MergePatchFormatter" {
+					t.Errorf("Got MergePatchFormatter, want %s", tt.wantType)
+				}
+			case *SideBySideFormatter:
+				if tt.wantType != "*diffnest.SideBySideFormatter" {
+					t.Errorf("Got SideBySideFormatter, want %s", tt.wantType)
+				}
+			case *HTMLFormatter:
+				if tt.wantType != "*diffnest.HTMLFormatter" {
+					t.Errorf("Got HTMLFormatter, want %s", tt.wantType)
+				}
+			case *MarkdownFormatter:
+				if tt.wantType != "*diffnest.MarkdownFormatter" {
+					t.Errorf("Got MarkdownFormatter, want %s", tt.wantType)
+				}
+			case *JSONFormatter:
+				if tt.wantType != "*diffnest.JSONFormatter" {
+					t.Errorf("Got JSONFormatter, want %s", tt.wantType)
+				}
+			case *PathsFormatter:
+				if tt.wantType != "*diffnest.PathsFormatter" {
+					t.Errorf("Got PathsFormatter, want %s", tt.wantType)
+				}
 			default:
 				t.Errorf("Unknown formatter type")
 			}
@@ -346,3 +672,150 @@ func TestCommand_Usage(t *testing.T) {
 		}
 	}
 }
+
+func TestApplyCommand_Parse(t *testing.T) {
+	tests := []struct {
+		name            string
+		args            []string
+		wantErr         bool
+		wantFormat      string
+		wantPatchFormat string
+	}{
+		{
+			name:            "Detect formats from filenames",
+			args:            []string{"config.yaml", "patch.json"},
+			wantFormat:      FormatYAML,
+			wantPatchFormat: FormatJSON,
+		},
+		{
+			name:            "Explicit formats",
+			args:            []string{"-format", "toml", "-patch-format", "yaml", "-type", "merge-patch", "-", "patch"},
+			wantFormat:      FormatTOML,
+			wantPatchFormat: FormatYAML,
+		},
+		{
+			name:    "Missing patch",
+			args:    []string{"config.yaml"},
+			wantErr: true,
+		},
+		{
+			name:    "Unsupported patch type",
+			args:    []string{"-type", "strategic", "config.yaml", "patch.json"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := NewApplyCommand("test", flag.ContinueOnError)
+			var buf bytes.Buffer
+			cmd.SetOutput(&buf)
+
+			err := cmd.Parse(tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+
+			if got := cmd.GetFormat(); got != tt.wantFormat {
+				t.Errorf("GetFormat() = %v, want %v", got, tt.wantFormat)
+			}
+			if got := cmd.GetPatchFormat(); got != tt.wantPatchFormat {
+				t.Errorf("GetPatchFormat() = %v, want %v", got, tt.wantPatchFormat)
+			}
+		})
+	}
+}
+
+func TestCommand_ColorEnabled(t *testing.T) {
+	tests := []struct {
+		name     string
+		color    string
+		terminal bool
+		noColor  string
+		want     bool
+	}{
+		{name: "Auto on terminal", color: "auto", terminal: true, want: true},
+		{name: "Auto on pipe", color: "auto", terminal: false, want: false},
+		{name: "Auto respects NO_COLOR", color: "auto", terminal: true, noColor: "1", want: false},
+		{name: "Always", color: "always", terminal: false, noColor: "1", want: true},
+		{name: "Never", color: "never", terminal: true, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("NO_COLOR", tt.noColor)
+
+			cmd := NewCommand("test", flag.ContinueOnError)
+			if err := cmd.Parse([]string{"-color", tt.color, "f1", "f2"}); err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			cmd.Terminal = tt.terminal
+
+			if got := cmd.ColorEnabled(); got != tt.want {
+				t.Errorf("ColorEnabled() = %v, want %v", got, tt.want)
+			}
+			if uf, ok := cmd.GetFormatter().(*UnifiedFormatter); !ok || uf.Color != tt.want {
+				t.Errorf("GetFormatter() Color = %v, want %v", uf.Color, tt.want)
+			}
+		})
+	}
+}
+
+func TestCommand_SideBySideWidth(t *testing.T) {
+	tests := []struct {
+		name          string
+		args          []string
+		terminalWidth int
+		want          int
+	}{
+		{name: "Terminal width", args: nil, terminalWidth: 160, want: 160},
+		{name: "Explicit width", args: []string{"-width", "100"}, terminalWidth: 160, want: 100},
+		{name: "Unknown width", args: nil, terminalWidth: 0, want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := NewCommand("test", flag.ContinueOnError)
+			args := append([]string{"-format", "side-by-side"}, tt.args...)
+			if err := cmd.Parse(append(args, "f1", "f2")); err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			cmd.TerminalWidth = tt.terminalWidth
+
+			formatter, ok := cmd.GetFormatter().(*SideBySideFormatter)
+			if !ok {
+				t.Fatalf("GetFormatter() = %T, want *SideBySideFormatter", cmd.GetFormatter())
+			}
+			if formatter.Width != tt.want {
+				t.Errorf("Width = %d, want %d", formatter.Width, tt.want)
+			}
+		})
+	}
+}
+
+func TestCommand_PathPatternFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "ignore.txt")
+	if err := os.WriteFile(file, []byte("# volatile\nmetadata.resourceVersion\nstatus\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := NewCommand("test", flag.ContinueOnError)
+	cmd.SetOutput(io.Discard)
+	if err := cmd.Parse([]string{"-ignore-path-file", file, "-ignore-path", "metadata.managedFields", "f1", "f2"}); err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if got := cmd.IgnorePaths.String(); got != "metadata.resourceVersion status metadata.managedFields" {
+		t.Errorf("IgnorePaths = %q", got)
+	}
+
+	cmd = NewCommand("test", flag.ContinueOnError)
+	cmd.SetOutput(io.Discard)
+	if err := cmd.Parse([]string{"-only-path-file", filepath.Join(t.TempDir(), "missing.txt"), "f1", "f2"}); err == nil {
+		t.Error("Parse() error = nil, want error for missing file")
+	}
+}
@@ -1,10 +1,104 @@
 package diffnest
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
 	"io"
 )
 
+// ErrMultipleDocuments is returned when a patch would apply to more than one document.
+var ErrMultipleDocuments = errors.New("expected a single document")
+
+// ParseError reports an input that could not be parsed.
+type ParseError struct {
+	Input string // "first file", "second file", "document" or "patch"
+	Err   error
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("error parsing %s: %v", e.Input, e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// FormatError reports results that could not be formatted, such as differences
+// a merge patch cannot express.
+type FormatError struct {
+	Err error
+}
+
+func (e *FormatError) Error() string {
+	return fmt.Sprintf("error formatting output: %v", e.Err)
+}
+
+func (e *FormatError) Unwrap() error {
+	return e.Err
+}
+
+// IOError reports a failure reading an input or writing the output.
+type IOError struct {
+	Op  string // e.g. "reading first file", "writing output"
+	Err error
+}
+
+func (e *IOError) Error() string {
+	return fmt.Sprintf("error %s: %v", e.Op, e.Err)
+}
+
+func (e *IOError) Unwrap() error {
+	return e.Err
+}
+
+// errorReader records the first error returned by the underlying reader,
+// telling I/O failures apart from invalid content.
+type errorReader struct {
+	r   io.Reader
+	err error
+}
+
+func (r *errorReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	if err != nil && !errors.Is(err, io.EOF) && r.err == nil {
+		r.err = err
+	}
+
+	return n, err //nolint:wrapcheck // Errors are passed through unchanged to the parser
+}
+
+// errorWriter records the first error returned by the underlying writer,
+// telling I/O failures apart from formatting failures.
+type errorWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (w *errorWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	if err != nil && w.err == nil {
+		w.err = err
+	}
+
+	return n, err //nolint:wrapcheck // Errors are passed through unchanged to the formatter
+}
+
+// parseInput parses r, returning an IOError when reading fails and a ParseError for invalid content.
+func parseInput(r io.Reader, format, input string) ([]*StructuredData, error) {
+	reader := &errorReader{r: r}
+
+	docs, err := ParseWithFormat(reader, format)
+	if reader.err != nil {
+		return nil, &IOError{Op: "reading " + input, Err: reader.err}
+	}
+	if err != nil {
+		return nil, &ParseError{Input: input, Err: err}
+	}
+
+	return docs, nil
+}
+
 // Controller handles the core diff logic.
 type Controller struct {
 	reader1   io.Reader
@@ -30,33 +124,101 @@ func NewController(reader1, reader2 io.Reader, format1, format2 string, diffOpts
 }
 
 // Run executes the diff process and returns whether differences were found.
+// With a nil formatter nothing is written. Errors are a *ParseError, *FormatError or *IOError.
 func (c *Controller) Run() (bool, error) {
-	docs1, err := ParseWithFormat(c.reader1, c.format1)
+	docs1, err := parseInput(c.reader1, c.format1, "first file")
 	if err != nil {
-		return false, fmt.Errorf("error parsing first file: %w", err)
+		return false, err
 	}
 
-	docs2, err := ParseWithFormat(c.reader2, c.format2)
+	docs2, err := parseInput(c.reader2, c.format2, "second file")
 	if err != nil {
-		return false, fmt.Errorf("error parsing second file: %w", err)
+		return false, err
 	}
 
 	results := Compare(docs1, docs2, c.diffOpts)
+	if c.formatter == nil {
+		return HasDifferences(results), nil
+	}
+
+	writer := &errorWriter{w: c.writer}
+	if err := c.formatter.Format(writer, results); err != nil {
+		if writer.err != nil {
+			return false, &IOError{Op: "writing output", Err: writer.err}
+		}
 
-	if err := c.formatter.Format(c.writer, results); err != nil {
-		return false, fmt.Errorf("error formatting output: %w", err)
+		return false, &FormatError{Err: err}
 	}
 
 	return HasDifferences(results), nil
 }
 
 // HasDifferences checks if there are any differences in the results.
+// Type-only changes are not differences.
 func HasDifferences(results []*DiffResult) bool {
 	for _, result := range results {
-		if result.Status != StatusSame {
+		if hasChanges(result) {
 			return true
 		}
 	}
 
 	return false
 }
+
+// PatchController handles applying a patch to a document.
+type PatchController struct {
+	docReader   io.Reader
+	patchReader io.Reader
+	format      string
+	patchFormat string
+	patchType   string
+	writer      io.Writer
+}
+
+// NewPatchController creates a new PatchController.
+func NewPatchController(docReader, patchReader io.Reader, format, patchFormat, patchType string, writer io.Writer) *PatchController {
+	return &PatchController{
+		docReader:   docReader,
+		patchReader: patchReader,
+		format:      format,
+		patchFormat: patchFormat,
+		patchType:   patchType,
+		writer:      writer,
+	}
+}
+
+// Run applies the patch and writes the patched document in the format of the original.
+// Nothing is written when the patch does not apply.
+func (c *PatchController) Run() error {
+	docs, err := parseInput(c.docReader, c.format, "document")
+	if err != nil {
+		return err
+	}
+	if len(docs) != 1 {
+		return &ParseError{Input: "document", Err: fmt.Errorf("%w, got %d", ErrMultipleDocuments, len(docs))}
+	}
+
+	patches, err := parseInput(c.patchReader, c.patchFormat, "patch")
+	if err != nil {
+		return err
+	}
+	if len(patches) != 1 {
+		return &ParseError{Input: "patch", Err: fmt.Errorf("%w, got %d", ErrMultipleDocuments, len(patches))}
+	}
+
+	patched, err := ApplyPatchDocument(docs[0], patches[0], c.patchType)
+	if err != nil {
+		return fmt.Errorf("error applying patch: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := EncodeWithFormat(&buf, []*StructuredData{patched}, c.format); err != nil {
+		return &FormatError{Err: err}
+	}
+
+	if _, err := buf.WriteTo(c.writer); err != nil {
+		return &IOError{Op: "writing document", Err: err}
+	}
+
+	return nil
+}
@@ -1,6 +1,8 @@
 package diffnest
 
 import (
+	"errors"
+	"io"
 	"strings"
 	"testing"
 )
@@ -328,8 +330,8 @@ department: Marketing`,
 				"data:",
 				"data.config:",
 				"logging.a: false",
-				"-      logging.b: false",
-				"+      logging.c: false",
+				"-      logging.[-b-]: false",
+				"+      logging.{+c+}: false",
 			},
 		},
 		{
@@ -420,6 +422,97 @@ department: Marketing`,
 	}
 }
 
+// failingIO fails every read and write.
+type failingIO struct{}
+
+var errFailingIO = errors.New("device unavailable")
+
+func (failingIO) Read([]byte) (int, error)  { return 0, errFailingIO }
+func (failingIO) Write([]byte) (int, error) { return 0, errFailingIO }
+
+func TestController_RunErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		reader1   string
+		failRead  bool
+		failWrite bool
+		formatter Formatter
+		check     func(t *testing.T, err error)
+	}{
+		{
+			name:      "Invalid content",
+			reader1:   `{"name":`,
+			formatter: &UnifiedFormatter{},
+			check: func(t *testing.T, err error) {
+				t.Helper()
+				var parseErr *ParseError
+				if !errors.As(err, &parseErr) || parseErr.Input != "first file" {
+					t.Errorf("Run() error = %v, want *ParseError for first file", err)
+				}
+			},
+		},
+		{
+			name:      "Unreadable input",
+			failRead:  true,
+			formatter: &UnifiedFormatter{},
+			check: func(t *testing.T, err error) {
+				t.Helper()
+				var ioErr *IOError
+				if !errors.As(err, &ioErr) || !errors.Is(err, errFailingIO) {
+					t.Errorf("Run() error = %v, want *IOError wrapping the read error", err)
+				}
+			},
+		},
+		{
+			name:      "Unwritable output",
+			reader1:   `{"name": "a"}`,
+			failWrite: true,
+			formatter: &UnifiedFormatter{},
+			check: func(t *testing.T, err error) {
+				t.Helper()
+				var ioErr *IOError
+				if !errors.As(err, &ioErr) || ioErr.Op != "writing output" {
+					t.Errorf("Run() error = %v, want *IOError writing output", err)
+				}
+			},
+		},
+		{
+			name:      "Unrepresentable output",
+			reader1:   `{"name": "a"}`,
+			formatter: &MergePatchFormatter{},
+			check: func(t *testing.T, err error) {
+				t.Helper()
+				var formatErr *FormatError
+				if !errors.As(err, &formatErr) || !errors.Is(err, ErrMergePatchUnrepresentable) {
+					t.Errorf("Run() error = %v, want *FormatError wrapping ErrMergePatchUnrepresentable", err)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var reader1 io.Reader = strings.NewReader(tt.reader1)
+			if tt.failRead {
+				reader1 = failingIO{}
+			}
+			var writer io.Writer = &strings.Builder{}
+			if tt.failWrite {
+				writer = failingIO{}
+			}
+
+			controller := NewController(reader1, strings.NewReader(`{"name": null}`), FormatJSON, FormatJSON,
+				DiffOptions{}, tt.formatter, writer)
+
+			_, err := controller.Run()
+			if err == nil {
+				t.Fatal("Run() error = nil, want error")
+			}
+			tt.check(t, err)
+		})
+	}
+}
+
 func TestHasDifferences(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -472,3 +565,108 @@ func TestHasDifferences(t *testing.T) {
 		})
 	}
 }
+
+func TestPatchController_Run(t *testing.T) {
+	tests := []struct {
+		name        string
+		doc         string
+		patch       string
+		format      string
+		patchFormat string
+		patchType   string
+		wantErr     bool
+		want        string
+	}{
+		{
+			name:        "JSON Patch on YAML keeps YAML",
+			doc:         "name: app\nreplicas: 1\n",
+			patch:       `[{"op": "replace", "path": "/replicas", "value": 3}]`,
+			format:      FormatYAML,
+			patchFormat: FormatJSON,
+			want:        "name: app\nreplicas: 3\n",
+		},
+		{
+			name:        "Merge patch written in YAML",
+			doc:         `{"name": "app", "debug": true}`,
+			patch:       "debug: null\nport: 80\n",
+			format:      FormatJSON,
+			patchFormat: FormatYAML,
+			want:        "{\n  \"name\": \"app\",\n  \"port\": 80\n}\n",
+		},
+		{
+			name:        "TOML keeps datetimes and key order",
+			doc:         "name = \"app\"\ndate = 2024-01-01\nwhen = 1979-05-27T07:32:00Z\n\n[server]\nport = 80\nhost = \"a\"\n",
+			patch:       `[{"op": "add", "path": "/server/id", "value": 1234567890123}]`,
+			format:      FormatTOML,
+			patchFormat: FormatJSON,
+			want:        "name = \"app\"\ndate = 2024-01-01\nwhen = 1979-05-27T07:32:00Z\n\n[server]\nport = 80\nhost = \"a\"\nid = 1234567890123\n",
+		},
+		{
+			name:        "TOML rejects integers beyond 64 bits",
+			doc:         "name = \"app\"\n",
+			patch:       `{"id": 12345678901234567890}`,
+			format:      FormatTOML,
+			patchFormat: FormatJSON,
+			wantErr:     true,
+		},
+		{
+			name:        "YAML keeps comments",
+			doc:         "# app\nname: app # the name\nports:\n  # web\n  - 80\n",
+			patch:       `[{"op": "add", "path": "/ports/-", "value": 443}]`,
+			format:      FormatYAML,
+			patchFormat: FormatJSON,
+			want:        "# app\nname: app # the name\nports:\n  # web\n  - 80\n  - 443\n",
+		},
+		{
+			name:        "Failing operation writes nothing",
+			doc:         "name: app\n",
+			patch:       `[{"op": "add", "path": "/a", "value": 1}, {"op": "remove", "path": "/missing"}]`,
+			format:      FormatYAML,
+			patchFormat: FormatJSON,
+			wantErr:     true,
+		},
+		{
+			name:        "Multiple documents",
+			doc:         "a: 1\n---\nb: 2\n",
+			patch:       `{"c": 3}`,
+			format:      FormatYAML,
+			patchFormat: FormatJSON,
+			wantErr:     true,
+		},
+		{
+			name:        "Unsupported patch type",
+			doc:         "a: 1\n",
+			patch:       `{"c": 3}`,
+			format:      FormatYAML,
+			patchFormat: FormatJSON,
+			patchType:   "strategic",
+			wantErr:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var output strings.Builder
+
+			controller := NewPatchController(
+				strings.NewReader(tt.doc),
+				strings.NewReader(tt.patch),
+				tt.format,
+				tt.patchFormat,
+				tt.patchType,
+				&output,
+			)
+
+			err := controller.Run()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Run() error = %v, wantErr %v", err, tt.wantErr)
+
+				return
+			}
+
+			if got := output.String(); got != tt.want {
+				t.Errorf("Run() output = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,7 +1,11 @@
 package diffnest
 
 import (
+	"encoding/json"
 	"fmt"
+	"math"
+	"math/big"
+	"slices"
 	"sort"
 	"strings"
 )
@@ -12,20 +16,69 @@ type DiffOptions struct {
 	IgnoreZeroValues  bool
 	IgnoreKeyCase     bool
 	IgnoreValueCase   bool
+	SortKeys          bool // Order object keys alphabetically instead of by source order
+	CompareComments   bool // Report comment changes as StatusCommentChanged
+	DetectMoves       bool // Report moved array elements and renamed object keys; ArrayStrategyIndex then compares as ArrayStrategyOrdered
 	ArrayDiffStrategy ArrayDiffStrategy
+	ArrayKeys         []ArrayKeyRule   // Identity keys used by ArrayStrategyKey
+	IgnorePaths       []PathPattern    // Values not compared
+	OnlyPaths         []PathPattern    // When set, only values matching one of them are compared
+	Tolerance         Tolerance        // Numbers within it compare equal
+	PathTolerances    []PathTolerance  // Tolerances at matching paths, the first match overriding Tolerance
+	Normalizers       []Normalizer     // Canonicalize values before comparing them
+	PathNormalizers   []PathNormalizer // Normalizers at matching paths, tried before Normalizers
+	CoerceTypes       bool             // Compare strings holding number, boolean or null literals with values of those types
+	ReportTypeChanges bool             // With CoerceTypes, report values equal only after coercion as StatusTypeChanged
 }
 
 // ArrayDiffStrategy defines how to compare arrays.
 type ArrayDiffStrategy int
 
 const (
-	ArrayStrategyIndex ArrayDiffStrategy = iota // Compare by index
-	ArrayStrategyValue                          // Find best matching
+	ArrayStrategyIndex   ArrayDiffStrategy = iota // Compare by index
+	ArrayStrategyValue                            // Find best matching
+	ArrayStrategyKey                              // Match elements by identity keys
+	ArrayStrategyOrdered                          // Longest common subsequence keeping order
 )
 
+// ArrayKeyRule defines the identity keys of the elements of arrays at a path.
+// Path is a dotted path without array indices, e.g. "spec.template.spec.containers".
+// It matches arrays whose path equals it or ends with it, so "containers" matches every containers array.
+type ArrayKeyRule struct {
+	Path string
+	Keys []string
+}
+
+// KubernetesArrayKeys returns rules mirroring the Kubernetes strategic merge patch merge keys.
+func KubernetesArrayKeys() []ArrayKeyRule {
+	return []ArrayKeyRule{
+		{Path: "containers", Keys: []string{"name"}},
+		{Path: "initContainers", Keys: []string{"name"}},
+		{Path: "ephemeralContainers", Keys: []string{"name"}},
+		{Path: "containers.ports", Keys: []string{"containerPort"}},
+		{Path: "initContainers.ports", Keys: []string{"containerPort"}},
+		{Path: "ephemeralContainers.ports", Keys: []string{"containerPort"}},
+		{Path: "spec.ports", Keys: []string{"port"}},
+		{Path: "env", Keys: []string{"name"}},
+		{Path: "volumeMounts", Keys: []string{"mountPath"}},
+		{Path: "volumeDevices", Keys: []string{"devicePath"}},
+		{Path: "volumes", Keys: []string{"name"}},
+		{Path: "imagePullSecrets", Keys: []string{"name"}},
+		{Path: "hostAliases", Keys: []string{"ip"}},
+		{Path: "topologySpreadConstraints", Keys: []string{"topologyKey"}},
+		{Path: "readinessGates", Keys: []string{"conditionType"}},
+		{Path: "resourceClaims", Keys: []string{"name"}},
+		{Path: "schedulingGates", Keys: []string{"name"}},
+		{Path: "conditions", Keys: []string{"type"}},
+		{Path: "ownerReferences", Keys: []string{"uid"}},
+		{Path: "secrets", Keys: []string{"name"}},
+	}
+}
+
 // DiffEngine computes differences between structures.
 type DiffEngine struct {
 	options DiffOptions
+	nodes   [][2]*StructuredData // Values being compared, from the root down, for path selectors
 }
 
 // NewDiffEngine creates a new diff engine.
@@ -39,6 +92,10 @@ func (e *DiffEngine) Compare(a, b *StructuredData) *DiffResult {
 }
 
 func (e *DiffEngine) compareWithPath(a, b *StructuredData, path []string) *DiffResult {
+	if e.excludedPath(path, a, b) {
+		return excludedResult(a, b, path)
+	}
+
 	// Handle nil cases
 	if a == nil && b == nil {
 		return &DiffResult{
@@ -65,6 +122,104 @@ func (e *DiffEngine) compareWithPath(a, b *StructuredData, path []string) *DiffR
 		}
 	}
 
+	e.nodes = append(e.nodes, [2]*StructuredData{a, b})
+	normalizedA, normalizedB := e.normalize(path, a, b)
+	result := e.compareValues(normalizedA, normalizedB, path)
+	e.nodes = e.nodes[:len(e.nodes)-1]
+
+	// Results show the values as written, not as normalized
+	if normalizedA != a || normalizedB != b {
+		result.From, result.To = a, b
+	}
+
+	if e.options.CompareComments {
+		e.compareComments(result, a, b)
+	}
+
+	return result
+}
+
+// excludedPath reports whether the value at path is left out of the comparison by
+// IgnorePaths or OnlyPaths. a and b are the values at path.
+func (e *DiffEngine) excludedPath(path []string, a, b *StructuredData) bool {
+	if len(path) == 0 || (len(e.options.IgnorePaths) == 0 && len(e.options.OnlyPaths) == 0) {
+		return false
+	}
+
+	nodes := e.pathNodes(path, a, b)
+
+	for _, pattern := range e.options.IgnorePaths {
+		if pattern.match(path, nodes)&(matchExact|matchInside) != 0 {
+			return true
+		}
+	}
+
+	if len(e.options.OnlyPaths) == 0 {
+		return false
+	}
+	// Values below path can only match when there is something below it.
+	hasChildren := isContainer(a) || isContainer(b)
+	for _, pattern := range e.options.OnlyPaths {
+		result := pattern.match(path, nodes)
+		if result&(matchExact|matchInside) != 0 || (result&matchBelow != 0 && hasChildren) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func isContainer(data *StructuredData) bool {
+	return data != nil && (data.Type == TypeObject || data.Type == TypeArray)
+}
+
+// pathNodes returns the values named by each segment of path, for matching path
+// patterns. a and b are the values at path; e.nodes holds their ancestors from
+// the root down.
+func (e *DiffEngine) pathNodes(path []string, a, b *StructuredData) [][2]*StructuredData {
+	nodes := make([][2]*StructuredData, 0, len(path))
+	if len(e.nodes) >= len(path) && len(path) > 0 {
+		nodes = append(nodes, e.nodes[1:len(path)]...)
+	}
+	for len(nodes) < len(path)-1 {
+		nodes = append(nodes, [2]*StructuredData{})
+	}
+
+	return append(nodes, [2]*StructuredData{a, b})
+}
+
+// excludedResult reports a value left out of the comparison as unchanged. A value
+// on one side only is reported as From and To.
+func excludedResult(a, b *StructuredData, path []string) *DiffResult {
+	if a == nil {
+		a = b
+	}
+	if b == nil {
+		b = a
+	}
+
+	return &DiffResult{
+		Status: StatusSame,
+		Path:   path,
+		From:   a,
+		To:     b,
+		Meta:   &DiffMeta{Excluded: true},
+	}
+}
+
+// isExcluded reports whether diff is a value left out of the comparison.
+func isExcluded(diff *DiffResult) bool {
+	return diff.Meta != nil && diff.Meta.Excluded
+}
+
+// compareValues compares two non-nil structured data by type and value.
+func (e *DiffEngine) compareValues(a, b *StructuredData, path []string) *DiffResult {
+	if a.Type != b.Type && e.options.CoerceTypes {
+		if coercedA, coercedB, ok := coerceTypes(a, b); ok {
+			return e.compareCoerced(a, b, coercedA, coercedB, path)
+		}
+	}
+
 	// Type mismatch
 	if a.Type != b.Type {
 		return &DiffResult{
@@ -140,7 +295,7 @@ func (e *DiffEngine) compareWithPath(a, b *StructuredData, path []string) *DiffR
 
 	case TypeNumber:
 		// Compare numbers with type conversion
-		if e.equalNumbers(a.Value, b.Value) {
+		if e.equalNumbers(a.Value, b.Value, e.toleranceFor(path, a, b)) {
 			return &DiffResult{
 				Status: StatusSame,
 				Path:   path,
@@ -161,7 +316,12 @@ func (e *DiffEngine) compareWithPath(a, b *StructuredData, path []string) *DiffR
 		return e.compareArrays(a, b, path)
 
 	case TypeObject:
-		return e.compareObjects(a, b, path)
+		result := e.compareObjects(a, b, path)
+		if e.options.DetectMoves {
+			e.detectMoves(result, StatusRenamed)
+		}
+
+		return result
 
 	default:
 		return &DiffResult{
@@ -174,14 +334,551 @@ func (e *DiffEngine) compareWithPath(a, b *StructuredData, path []string) *DiffR
 	}
 }
 
+// compareCoerced compares a and b as coerced to the same type. Equal values are
+// StatusSame, or StatusTypeChanged with ReportTypeChanges.
+func (e *DiffEngine) compareCoerced(a, b, coercedA, coercedB *StructuredData, path []string) *DiffResult {
+	result := e.compareValues(coercedA, coercedB, path)
+	result.From, result.To = a, b
+	if result.Status == StatusSame && e.options.ReportTypeChanges {
+		result.Status = StatusTypeChanged
+	}
+
+	return result
+}
+
+// compareComments marks result when the comments attached to a and b differ.
+// A result whose values are the same becomes StatusCommentChanged.
+func (e *DiffEngine) compareComments(result *DiffResult, a, b *StructuredData) {
+	var commentsA, commentsB []string
+	if a.Meta != nil {
+		commentsA = a.Meta.Comments
+	}
+	if b.Meta != nil {
+		commentsB = b.Meta.Comments
+	}
+
+	if slices.Equal(commentsA, commentsB) {
+		return
+	}
+
+	if result.Meta == nil {
+		result.Meta = &DiffMeta{}
+	}
+	result.Meta.CommentChanged = true
+	result.Meta.DiffCount++
+
+	if result.Status == StatusSame {
+		result.Status = StatusCommentChanged
+	}
+}
+
 func (e *DiffEngine) compareArrays(a, b *StructuredData, path []string) *DiffResult {
-	if e.options.ArrayDiffStrategy == ArrayStrategyValue {
+	switch e.options.ArrayDiffStrategy {
+	case ArrayStrategyValue:
+		return e.compareArraysByValue(a, b, path)
+	case ArrayStrategyKey:
+		if keys := e.arrayKeysFor(path); len(keys) > 0 {
+			if result, ok := e.compareArraysByKey(a, b, path, keys); ok {
+				return result
+			}
+		}
+
 		return e.compareArraysByValue(a, b, path)
+	case ArrayStrategyOrdered:
+		result := e.compareArraysOrdered(a, b, path)
+		if e.options.DetectMoves {
+			e.detectMoves(result, StatusMoved)
+		}
+
+		return result
+	}
+
+	if e.options.DetectMoves {
+		// Moves are found among the insertions and deletions of the ordered diff,
+		// which positional comparison would report as modifications
+		result := e.compareArraysOrdered(a, b, path)
+		e.detectMoves(result, StatusMoved)
+
+		return result
 	}
 
 	return e.compareArraysByIndex(a, b, path)
 }
 
+// detectMoves pairs deleted and added children holding equal or similar subtrees.
+// Each pair becomes a single child with the given status, FromPath set to the deleted path
+// and Path to the added path. Differences between similar subtrees are kept as its children.
+func (e *DiffEngine) detectMoves(result *DiffResult, status DiffStatus) {
+	var deleted, added []int
+	for i, child := range result.Children {
+		switch child.Status {
+		case StatusDeleted:
+			deleted = append(deleted, i)
+		case StatusAdded:
+			added = append(added, i)
+		}
+	}
+
+	if len(deleted) == 0 || len(added) == 0 {
+		return
+	}
+
+	removed := make(map[int]bool)
+	replaced := make(map[int]*DiffResult)
+
+	for _, di := range deleted {
+		source := result.Children[di]
+		best := -1
+		var bestDiff *DiffResult
+
+		for _, ai := range added {
+			if replaced[ai] != nil {
+				continue
+			}
+
+			target := result.Children[ai]
+			if status == StatusRenamed && !isContainer(source.From) && !relatedKeys(source.Path, target.Path) {
+				continue
+			}
+			diff := e.compareWithPath(source.From, target.To, target.Path)
+			if !e.isSimilar(diff, source.From, target.To) {
+				continue
+			}
+			if best < 0 || diffCount(diff) < diffCount(bestDiff) {
+				best = ai
+				bestDiff = diff
+			}
+		}
+
+		if best < 0 {
+			continue
+		}
+
+		moved := &DiffResult{
+			Status:   status,
+			Path:     result.Children[best].Path,
+			FromPath: source.Path,
+			From:     source.From,
+			To:       result.Children[best].To,
+			Meta:     &DiffMeta{DiffCount: 1 + diffCount(bestDiff)},
+		}
+		if bestDiff.Status != StatusSame {
+			moved.Children = bestDiff.Children
+		}
+
+		removed[di] = true
+		replaced[best] = moved
+	}
+
+	children := make([]*DiffResult, 0, len(result.Children)-len(removed))
+	diffTotal := 0
+	for i, child := range result.Children {
+		if removed[i] {
+			continue
+		}
+		if moved, ok := replaced[i]; ok {
+			child = moved
+		}
+		if child.Status != StatusSame {
+			diffTotal += diffCount(child)
+		}
+		children = append(children, child)
+	}
+
+	result.Children = children
+	result.Meta.DiffCount = diffTotal
+}
+
+// relatedKeys reports whether the last segments of two paths are keys where one contains
+// the other, ignoring case, such as timeout and timeoutSeconds.
+// Scalars are only paired as renames under related keys, as unrelated keys often hold equal values.
+func relatedKeys(a, b []string) bool {
+	if len(a) == 0 || len(b) == 0 {
+		return false
+	}
+	keyA := strings.ToLower(a[len(a)-1])
+	keyB := strings.ToLower(b[len(b)-1])
+
+	return strings.Contains(keyA, keyB) || strings.Contains(keyB, keyA)
+}
+
+// isSimilar reports whether a and b are equal, or are containers of the same type
+// where at most half of the larger subtree differs.
+func (e *DiffEngine) isSimilar(diff *DiffResult, a, b *StructuredData) bool {
+	if !hasChanges(diff) {
+		return true
+	}
+
+	if a.Type != b.Type || !isContainer(a) {
+		return false
+	}
+
+	size := max(e.calculateSize(a), e.calculateSize(b))
+
+	return size >= 2 && diffCount(diff)*2 <= size
+}
+
+// hasChanges reports whether diff holds differences other than type-only changes.
+func hasChanges(diff *DiffResult) bool {
+	switch diff.Status {
+	case StatusSame, StatusTypeChanged:
+		return false
+	case StatusModified:
+		if len(diff.Children) == 0 || (diff.Meta != nil && diff.Meta.CommentChanged) {
+			return true
+		}
+
+		return slices.ContainsFunc(diff.Children, hasChanges)
+	case StatusAdded, StatusDeleted, StatusCommentChanged, StatusMoved, StatusRenamed:
+	}
+
+	return true
+}
+
+// isChange reports whether the child result diff makes its parent StatusModified.
+// Type-only changes leave the parent StatusSame.
+func isChange(diff *DiffResult) bool {
+	return diff.Status != StatusSame && diff.Status != StatusTypeChanged
+}
+
+// hasTypeChanges reports whether diff or a value inside it is StatusTypeChanged,
+// so that formatters show type changes inside unchanged containers.
+func hasTypeChanges(diff *DiffResult) bool {
+	return diff.Status == StatusTypeChanged || slices.ContainsFunc(diff.Children, hasTypeChanges)
+}
+
+// diffCount returns the size of the difference of diff.
+func diffCount(diff *DiffResult) int {
+	if diff == nil || diff.Meta == nil {
+		return 0
+	}
+
+	return diff.Meta.DiffCount
+}
+
+// compareArraysOrdered compares arrays using the shortest edit script (Myers algorithm),
+// so insertions and deletions do not shift the comparison of the following elements.
+// Runs of deletions followed by insertions are paired up as modifications.
+// Paths hold the index in the first array, except for inserted elements, which
+// only exist in the second array and hold their index there.
+func (e *DiffEngine) compareArraysOrdered(a, b *StructuredData, path []string) *DiffResult {
+	result := &DiffResult{
+		Status:   StatusSame,
+		Path:     path,
+		From:     a,
+		To:       b,
+		Children: []*DiffResult{},
+		Meta:     &DiffMeta{DiffCount: 0},
+	}
+
+	// Equal pairs found while searching for the edit script, reused for the results
+	matched := make(map[[2]int]*DiffResult)
+	comparePair := func(i, j int) *DiffResult {
+		if diff, ok := matched[[2]int{i, j}]; ok {
+			return diff
+		}
+		childPath := append(append([]string{}, path...), fmt.Sprintf("[%d]", i))
+
+		return e.compareWithPath(a.Elements[i], b.Elements[j], childPath)
+	}
+
+	addResult := func(childDiff *DiffResult) {
+		if isChange(childDiff) {
+			result.Status = StatusModified
+			if childDiff.Meta != nil {
+				result.Meta.DiffCount += childDiff.Meta.DiffCount
+			}
+		}
+		result.Children = append(result.Children, childDiff)
+	}
+	addOneSided := func(elemA, elemB *StructuredData, index int) {
+		childPath := append(append([]string{}, path...), fmt.Sprintf("[%d]", index))
+		addResult(e.compareWithPath(elemA, elemB, childPath))
+	}
+
+	matches, ok := myersMatches(len(a.Elements), len(b.Elements), func(i, j int) bool {
+		diff := comparePair(i, j)
+		if hasChanges(diff) {
+			return false
+		}
+		matched[[2]int{i, j}] = diff
+
+		return true
+	})
+	if !ok {
+		return e.compareArraysByIndex(a, b, path)
+	}
+	// Sentinel match closing the last hunk
+	matches = append(matches, [2]int{len(a.Elements), len(b.Elements)})
+
+	i, j := 0, 0
+	for _, m := range matches {
+		deleted := m[0] - i
+		inserted := m[1] - j
+
+		for k := range min(deleted, inserted) {
+			addResult(comparePair(i+k, j+k))
+		}
+		for k := inserted; k < deleted; k++ {
+			addOneSided(a.Elements[i+k], nil, i+k)
+		}
+		for k := deleted; k < inserted; k++ {
+			addOneSided(nil, b.Elements[j+k], j+k)
+		}
+
+		if m[0] < len(a.Elements) {
+			addResult(comparePair(m[0], m[1]))
+		}
+		i, j = m[0]+1, m[1]+1
+	}
+
+	return result
+}
+
+// Limits of the edit script search; ordered arrays beyond them are compared by index.
+const (
+	myersMaxEdits       = 1000    // Edit distance searched
+	myersMaxComparisons = 1 << 18 // Element comparisons made while searching
+)
+
+// myersMatches returns the index pairs of equal elements kept by the shortest edit script
+// between sequences of length n and m, in order.
+// The common prefix and suffix are matched directly; ok is false when the rest needs
+// more edits or comparisons than the search limits allow.
+func myersMatches(n, m int, equal func(i, j int) bool) (matches [][2]int, ok bool) {
+	prefix := 0
+	for prefix < n && prefix < m && equal(prefix, prefix) {
+		prefix++
+	}
+	suffix := 0
+	for suffix < n-prefix && suffix < m-prefix && equal(n-1-suffix, m-1-suffix) {
+		suffix++
+	}
+
+	middle, ok := myersSearch(n-prefix-suffix, m-prefix-suffix, func(i, j int) bool {
+		return equal(prefix+i, prefix+j)
+	})
+	if !ok {
+		return nil, false
+	}
+
+	matches = make([][2]int, 0, prefix+len(middle)+suffix)
+	for i := range prefix {
+		matches = append(matches, [2]int{i, i})
+	}
+	for _, match := range middle {
+		matches = append(matches, [2]int{prefix + match[0], prefix + match[1]})
+	}
+	for i := range suffix {
+		matches = append(matches, [2]int{n - suffix + i, m - suffix + i})
+	}
+
+	return matches, true
+}
+
+// myersSearch runs the Myers forward search and backtracks the shortest edit script.
+// Only the diagonals reachable at each edit distance are kept for the backtrack.
+func myersSearch(n, m int, equal func(i, j int) bool) ([][2]int, bool) {
+	if n == 0 || m == 0 {
+		return nil, true
+	}
+
+	maxD := min(n+m, myersMaxEdits)
+	offset := maxD + 1
+	v := make([]int, 2*maxD+3)
+	// trace[d] holds the furthest reaching x of diagonals -d..d after d edits
+	var trace [][]int
+	comparisons := 0
+
+	found := false
+	for d := 0; d <= maxD && !found; d++ {
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m {
+				comparisons++
+				if comparisons > myersMaxComparisons {
+					return nil, false
+				}
+				if !equal(x, y) {
+					break
+				}
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				found = true
+
+				break
+			}
+		}
+		trace = append(trace, slices.Clone(v[offset-d:offset+d+1]))
+	}
+	if !found {
+		return nil, false
+	}
+
+	// Backtrack through the trace collecting diagonal moves
+	var matches [][2]int
+	x, y := n, m
+	for d := len(trace) - 1; d > 0; d-- {
+		prev := trace[d-1]
+		at := func(k int) int { return prev[k+d-1] }
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && at(k-1) < at(k+1)) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := at(prevK)
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			matches = append(matches, [2]int{x - 1, y - 1})
+			x--
+			y--
+		}
+		x, y = prevX, prevY
+	}
+	for x > 0 && y > 0 {
+		matches = append(matches, [2]int{x - 1, y - 1})
+		x--
+		y--
+	}
+
+	slices.Reverse(matches)
+
+	return matches, true
+}
+
+// arrayKeysFor returns the identity keys of the most specific rule matching path.
+func (e *DiffEngine) arrayKeysFor(path []string) []string {
+	segments := make([]string, 0, len(path))
+	for _, segment := range path {
+		if !strings.HasPrefix(segment, "[") {
+			segments = append(segments, segment)
+		}
+	}
+	normalized := strings.Join(segments, ".")
+
+	var keys []string
+	bestLen := -1
+	for _, rule := range e.options.ArrayKeys {
+		if normalized != rule.Path && !strings.HasSuffix(normalized, "."+rule.Path) {
+			continue
+		}
+		if len(rule.Path) > bestLen {
+			bestLen = len(rule.Path)
+			keys = rule.Keys
+		}
+	}
+
+	return keys
+}
+
+// compareArraysByKey pairs array elements with the same identity keys.
+// It returns false when an element has no identity or identities are not unique,
+// leaving the comparison to another strategy.
+func (e *DiffEngine) compareArraysByKey(a, b *StructuredData, path []string, keys []string) (*DiffResult, bool) {
+	idsA, ok := elementIdentities(a.Elements, keys)
+	if !ok {
+		return nil, false
+	}
+	idsB, ok := elementIdentities(b.Elements, keys)
+	if !ok {
+		return nil, false
+	}
+
+	indexB := make(map[string]int, len(idsB))
+	for j, id := range idsB {
+		indexB[id] = j
+	}
+
+	result := &DiffResult{
+		Status:   StatusSame,
+		Path:     path,
+		From:     a,
+		To:       b,
+		Children: []*DiffResult{},
+		Meta:     &DiffMeta{DiffCount: 0},
+	}
+
+	addChild := func(childDiff *DiffResult) {
+		if isChange(childDiff) {
+			result.Status = StatusModified
+			if childDiff.Meta != nil {
+				result.Meta.DiffCount += childDiff.Meta.DiffCount
+			}
+		}
+		result.Children = append(result.Children, childDiff)
+	}
+
+	// Elements of A in order, either matched or deleted
+	matchedB := make(map[int]bool, len(idsB))
+	for i, id := range idsA {
+		childPath := append(append([]string{}, path...), "["+id+"]")
+
+		var elemB *StructuredData
+		if j, ok := indexB[id]; ok {
+			elemB = b.Elements[j]
+			matchedB[j] = true
+		}
+		addChild(e.compareWithPath(a.Elements[i], elemB, childPath))
+	}
+
+	// Elements only in B
+	for j, id := range idsB {
+		if !matchedB[j] {
+			childPath := append(append([]string{}, path...), "["+id+"]")
+			addChild(e.compareWithPath(nil, b.Elements[j], childPath))
+		}
+	}
+
+	return result, true
+}
+
+// elementIdentities builds identities like "name=app" from the identity keys of each element.
+// It returns false when an element lacks a primitive value for a key or identities are duplicated.
+func elementIdentities(elements []*StructuredData, keys []string) ([]string, bool) {
+	ids := make([]string, len(elements))
+	seen := make(map[string]bool, len(elements))
+
+	for i, elem := range elements {
+		if elem == nil || elem.Type != TypeObject {
+			return nil, false
+		}
+
+		parts := make([]string, 0, len(keys))
+		for _, key := range keys {
+			value, ok := elem.Children[key]
+			if !ok {
+				return nil, false
+			}
+			switch value.Type {
+			case TypeString, TypeNumber, TypeBool:
+				parts = append(parts, fmt.Sprintf("%s=%v", key, value.Value))
+			default:
+				return nil, false
+			}
+		}
+
+		id := strings.Join(parts, ",")
+		if seen[id] {
+			return nil, false
+		}
+		seen[id] = true
+		ids[i] = id
+	}
+
+	return ids, true
+}
+
 func (e *DiffEngine) compareArraysByIndex(a, b *StructuredData, path []string) *DiffResult {
 	result := &DiffResult{
 		Status:   StatusSame,
@@ -210,7 +907,7 @@ func (e *DiffEngine) compareArraysByIndex(a, b *StructuredData, path []string) *
 		childPath := append(append([]string{}, path...), fmt.Sprintf("[%d]", i))
 		childDiff := e.compareWithPath(elemA, elemB, childPath)
 
-		if childDiff.Status != StatusSame {
+		if isChange(childDiff) {
 			result.Status = StatusModified
 			if childDiff.Meta != nil {
 				result.Meta.DiffCount += childDiff.Meta.DiffCount
@@ -246,7 +943,8 @@ func (e *DiffEngine) compareArraysByValue(a, b *StructuredData, path []string) *
 	// Compare all pairs
 	for i, elemA := range a.Elements {
 		for j, elemB := range b.Elements {
-			childDiff := e.compareWithPath(elemA, elemB, []string{})
+			childPath := append(append([]string{}, path...), fmt.Sprintf("[%d]", i))
+			childDiff := e.compareWithPath(elemA, elemB, childPath)
 			cost := 0
 			if childDiff.Meta != nil {
 				cost = childDiff.Meta.DiffCount
@@ -260,8 +958,8 @@ func (e *DiffEngine) compareArraysByValue(a, b *StructuredData, path []string) *
 		}
 	}
 
-	// Sort by cost (best matches first)
-	sort.Slice(matches, func(i, j int) bool {
+	// Sort by cost (best matches first), keeping the original order for equal costs
+	sort.SliceStable(matches, func(i, j int) bool {
 		return matches[i].cost < matches[j].cost
 	})
 
@@ -284,9 +982,11 @@ func (e *DiffEngine) compareArraysByValue(a, b *StructuredData, path []string) *
 			childPath := append(append([]string{}, path...), fmt.Sprintf("[%d]", i))
 			childDiff := e.compareWithPath(a.Elements[i], nil, childPath)
 			result.Children = append(result.Children, childDiff)
-			result.Status = StatusModified
-			if childDiff.Meta != nil {
-				result.Meta.DiffCount += childDiff.Meta.DiffCount
+			if isChange(childDiff) {
+				result.Status = StatusModified
+				if childDiff.Meta != nil {
+					result.Meta.DiffCount += childDiff.Meta.DiffCount
+				}
 			}
 		}
 	}
@@ -297,19 +997,19 @@ func (e *DiffEngine) compareArraysByValue(a, b *StructuredData, path []string) *
 			childPath := append(append([]string{}, path...), fmt.Sprintf("[%d]", j))
 			childDiff := e.compareWithPath(nil, b.Elements[j], childPath)
 			result.Children = append(result.Children, childDiff)
-			result.Status = StatusModified
-			if childDiff.Meta != nil {
-				result.Meta.DiffCount += childDiff.Meta.DiffCount
+			if isChange(childDiff) {
+				result.Status = StatusModified
+				if childDiff.Meta != nil {
+					result.Meta.DiffCount += childDiff.Meta.DiffCount
+				}
 			}
 		}
 	}
 
 	// Add matched elements
 	for _, m := range finalMatches {
-		childPath := append(append([]string{}, path...), fmt.Sprintf("[%d]", m.indexA))
-		m.diff.Path = childPath
 		result.Children = append(result.Children, m.diff)
-		if m.diff.Status != StatusSame {
+		if isChange(m.diff) {
 			result.Status = StatusModified
 			if m.diff.Meta != nil {
 				result.Meta.DiffCount += m.diff.Meta.DiffCount
@@ -334,21 +1034,7 @@ func (e *DiffEngine) compareObjects(a, b *StructuredData, path []string) *DiffRe
 		return e.compareObjectsIgnoreCase(a, b, path, result)
 	}
 
-	// Collect all keys
-	allKeys := make(map[string]bool)
-	for k := range a.Children {
-		allKeys[k] = true
-	}
-	for k := range b.Children {
-		allKeys[k] = true
-	}
-
-	// Sort keys for consistent output
-	keys := make([]string, 0, len(allKeys))
-	for k := range allKeys {
-		keys = append(keys, k)
-	}
-	sort.Strings(keys)
+	keys := e.orderKeys(a.ChildKeys(), b.ChildKeys())
 
 	// Compare each key
 	for _, key := range keys {
@@ -368,10 +1054,13 @@ func (e *DiffEngine) compareObjects(a, b *StructuredData, path []string) *DiffRe
 		if !hasB {
 			childB = nil
 		}
+		if e.excludedPath(childPath, childA, childB) {
+			continue
+		}
 
 		childDiff := e.compareWithPath(childA, childB, childPath)
 
-		if childDiff.Status != StatusSame {
+		if isChange(childDiff) {
 			result.Status = StatusModified
 			if childDiff.Meta != nil {
 				result.Meta.DiffCount += childDiff.Meta.DiffCount
@@ -389,31 +1078,25 @@ func (e *DiffEngine) compareObjectsIgnoreCase(a, b *StructuredData, path []strin
 	// Create case-insensitive key mappings
 	aKeyMap := make(map[string]string) // lowercase -> original
 	bKeyMap := make(map[string]string) // lowercase -> original
+	aLowerKeys := make([]string, 0, len(a.Children))
+	bLowerKeys := make([]string, 0, len(b.Children))
 
-	for k := range a.Children {
+	for _, k := range a.ChildKeys() {
 		lowerK := strings.ToLower(k)
+		if _, exists := aKeyMap[lowerK]; !exists {
+			aLowerKeys = append(aLowerKeys, lowerK)
+		}
 		aKeyMap[lowerK] = k
 	}
-	for k := range b.Children {
+	for _, k := range b.ChildKeys() {
 		lowerK := strings.ToLower(k)
+		if _, exists := bKeyMap[lowerK]; !exists {
+			bLowerKeys = append(bLowerKeys, lowerK)
+		}
 		bKeyMap[lowerK] = k
 	}
 
-	// Collect all lowercase keys
-	allLowerKeys := make(map[string]bool)
-	for lowerK := range aKeyMap {
-		allLowerKeys[lowerK] = true
-	}
-	for lowerK := range bKeyMap {
-		allLowerKeys[lowerK] = true
-	}
-
-	// Sort lowercase keys for consistent output
-	lowerKeys := make([]string, 0, len(allLowerKeys))
-	for lowerK := range allLowerKeys {
-		lowerKeys = append(lowerKeys, lowerK)
-	}
-	sort.Strings(lowerKeys)
+	lowerKeys := e.orderKeys(aLowerKeys, bLowerKeys)
 
 	// Compare each key (case-insensitive)
 	for _, lowerKey := range lowerKeys {
@@ -447,10 +1130,13 @@ func (e *DiffEngine) compareObjectsIgnoreCase(a, b *StructuredData, path []strin
 		if !hasB {
 			childB = nil
 		}
+		if e.excludedPath(childPath, childA, childB) {
+			continue
+		}
 
 		childDiff := e.compareWithPath(childA, childB, childPath)
 
-		if childDiff.Status != StatusSame {
+		if isChange(childDiff) {
 			result.Status = StatusModified
 			if childDiff.Meta != nil {
 				result.Meta.DiffCount += childDiff.Meta.DiffCount
@@ -463,7 +1149,49 @@ func (e *DiffEngine) compareObjectsIgnoreCase(a, b *StructuredData, path []strin
 	return result
 }
 
-func (e *DiffEngine) equalNumbers(a, b any) bool {
+// orderKeys merges the key orders of two objects.
+// Keys of a keep their order and keys only in b are placed after the key preceding them in b.
+// With SortKeys, all keys are sorted alphabetically instead.
+func (e *DiffEngine) orderKeys(aKeys, bKeys []string) []string {
+	inA := make(map[string]bool, len(aKeys))
+	for _, k := range aKeys {
+		inA[k] = true
+	}
+
+	// Keys only in b, grouped by the closest preceding key that also exists in a
+	following := make(map[string][]string)
+	var leading []string
+	prev := ""
+	hasPrev := false
+	for _, k := range bKeys {
+		if inA[k] {
+			prev = k
+			hasPrev = true
+
+			continue
+		}
+		if hasPrev {
+			following[prev] = append(following[prev], k)
+		} else {
+			leading = append(leading, k)
+		}
+	}
+
+	keys := make([]string, 0, len(aKeys)+len(bKeys))
+	keys = append(keys, leading...)
+	for _, k := range aKeys {
+		keys = append(keys, k)
+		keys = append(keys, following[k]...)
+	}
+
+	if e.options.SortKeys {
+		sort.Strings(keys)
+	}
+
+	return keys
+}
+
+func (e *DiffEngine) equalNumbers(a, b any, tolerance Tolerance) bool {
 	// Convert both values to float64 for comparison
 	aFloat := toFloat64(a)
 	bFloat := toFloat64(b)
@@ -472,13 +1200,27 @@ func (e *DiffEngine) equalNumbers(a, b any) bool {
 	aInt, aIsInt := toInt64(a)
 	bInt, bIsInt := toInt64(b)
 
-	// If both are integers, compare as integers
+	// If both are integers, compare as integers, otherwise exactly as rationals
+	var equal bool
 	if aIsInt && bIsInt {
-		return aInt == bInt
+		equal = aInt == bInt
+	} else if aRat, ok := numberRat(a); ok {
+		bRat, ok := numberRat(b)
+		equal = ok && aRat.Cmp(bRat) == 0
+	} else {
+		// NaN and infinities have no rational value. Two NaNs are the same value
+		// written twice, so they are equal here, unlike in floating point.
+		equal = aFloat == bFloat || (math.IsNaN(aFloat) && math.IsNaN(bFloat))
 	}
+	if equal || tolerance.IsZero() || !isFinite(aFloat) || !isFinite(bFloat) {
+		return equal
+	}
+
+	return tolerance.within(aFloat, bFloat)
+}
 
-	// Otherwise compare as floats
-	return aFloat == bFloat
+func isFinite(f float64) bool {
+	return !math.IsNaN(f) && !math.IsInf(f, 0)
 }
 
 // toFloat64 converts various numeric types to float64.
@@ -508,6 +1250,14 @@ func toFloat64(v any) float64 {
 		return float64(val)
 	case float64:
 		return val
+	case json.Number:
+		f, _ := val.Float64()
+
+		return f
+	case *big.Rat:
+		f, _ := val.Float64()
+
+		return f
 	default:
 		return 0
 	}
@@ -553,6 +1303,10 @@ func toInt64(v any) (int64, bool) {
 		}
 
 		return 0, false
+	case json.Number:
+		i, err := val.Int64()
+
+		return i, err == nil
 	default:
 		return 0, false
 	}
@@ -577,7 +1331,9 @@ func (e *DiffEngine) shouldIgnore(data *StructuredData, exists bool) bool {
 	case TypeBool:
 		return data.Value == false
 	case TypeNumber:
-		return data.Value == 0 || data.Value == 0.0
+		zero, ok := numberRat(data.Value)
+
+		return ok && zero.Sign() == 0
 	case TypeString:
 		return data.Value == ""
 	case TypeArray:
@@ -622,7 +1378,7 @@ func Compare(docsA, docsB []*StructuredData, options DiffOptions) []*DiffResult
 
 	// If single documents, compare directly
 	if len(docsA) == 1 && len(docsB) == 1 {
-		return []*DiffResult{engine.Compare(docsA[0], docsB[0])}
+		return []*DiffResult{withDocumentPair(engine.Compare(docsA[0], docsB[0]), 0, 0)}
 	}
 
 	// Build cost matrix for Hungarian algorithm
@@ -727,10 +1483,10 @@ func Compare(docsA, docsB []*StructuredData, options DiffOptions) []*DiffResult
 		j := assignment[i]
 		if j < len(docsB) {
 			// docsA[i] matched with docsB[j]
-			results = append(results, diffResults[i][j])
+			results = append(results, withDocumentPair(diffResults[i][j], i, j))
 		} else {
 			// docsA[i] was deleted
-			results = append(results, deleteDiffs[i])
+			results = append(results, withDocumentPair(deleteDiffs[i], i, -1))
 		}
 	}
 
@@ -745,13 +1501,23 @@ func Compare(docsA, docsB []*StructuredData, options DiffOptions) []*DiffResult
 
 	for j := range docsB {
 		if !matchedB[j] {
-			results = append(results, addDiffs[j])
+			results = append(results, withDocumentPair(addDiffs[j], -1, j))
 		}
 	}
 
 	return results
 }
 
+// withDocumentPair records the indices of the documents compared in result.
+func withDocumentPair(result *DiffResult, from, to int) *DiffResult {
+	if result.Meta == nil {
+		result.Meta = &DiffMeta{}
+	}
+	result.Meta.Document = &DocumentPair{From: from, To: to}
+
+	return result
+}
+
 // hungarianAlgorithm implements the Hungarian algorithm for optimal assignment.
 // Returns an assignment where assignment[i] is the column assigned to row i.
 func hungarianAlgorithm(costMatrix [][]int) []int {
@@ -895,6 +1661,9 @@ func fieldValuesMatch(a, b *StructuredData, fieldName string) bool {
 	if fieldA.Type != fieldB.Type {
 		return false
 	}
+	if fieldA.Type == TypeNumber {
+		return (&DiffEngine{}).equalNumbers(fieldA.Value, fieldB.Value, Tolerance{})
+	}
 
 	return fieldA.Value == fieldB.Value
 }
@@ -950,26 +1719,27 @@ func (e *DiffEngine) compareMultilineStrings(a, b *StructuredData, path []string
 		Meta:     b.Meta,
 	}
 
+	// Comments belong to the whole string, not to its lines
+	aLineMeta := withoutComments(a.Meta)
+	bLineMeta := withoutComments(b.Meta)
+
 	for i, line := range aLines {
 		aArray.Elements[i] = &StructuredData{
 			Type:  TypeString,
 			Value: line,
-			Meta:  a.Meta,
+			Meta:  aLineMeta,
 		}
 	}
 	for i, line := range bLines {
 		bArray.Elements[i] = &StructuredData{
 			Type:  TypeString,
 			Value: line,
-			Meta:  b.Meta,
+			Meta:  bLineMeta,
 		}
 	}
 
-	// Use array comparison with index strategy to preserve line order
-	oldStrategy := e.options.ArrayDiffStrategy
-	e.options.ArrayDiffStrategy = ArrayStrategyIndex
-	arrayResult := e.compareArrays(aArray, bArray, path)
-	e.options.ArrayDiffStrategy = oldStrategy
+	// Use ordered array comparison so inserted or deleted lines do not shift the following lines
+	arrayResult := e.compareArraysOrdered(aArray, bArray, path)
 
 	// Convert back to string result
 	result := &DiffResult{
@@ -999,3 +1769,16 @@ func (e *DiffEngine) compareMultilineStrings(a, b *StructuredData, path []string
 
 	return result
 }
+
+// withoutComments returns a copy of meta without comments.
+func withoutComments(meta *Metadata) *Metadata {
+	if meta == nil {
+		return nil
+	}
+
+	stripped := *meta
+	stripped.Comments = nil
+	stripped.yamlComments = nil
+
+	return &stripped
+}
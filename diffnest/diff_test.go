@@ -1,6 +1,7 @@
 package diffnest
 
 import (
+	"fmt"
 	"strings"
 	"testing"
 )
@@ -235,6 +236,112 @@ func TestDiffEngine_CompareArrays(t *testing.T) {
 	}
 }
 
+func TestDiffEngine_CompareArraysByKey(t *testing.T) {
+	container := func(name, image string) *StructuredData {
+		return &StructuredData{
+			Type: TypeObject,
+			Children: map[string]*StructuredData{
+				"name":  {Type: TypeString, Value: name},
+				"image": {Type: TypeString, Value: image},
+			},
+		}
+	}
+	containers := func(elements ...*StructuredData) *StructuredData {
+		return &StructuredData{
+			Type: TypeObject,
+			Children: map[string]*StructuredData{
+				"containers": {Type: TypeArray, Elements: elements},
+			},
+		}
+	}
+
+	tests := []struct {
+		name      string
+		a         *StructuredData
+		b         *StructuredData
+		keys      []ArrayKeyRule
+		wantPaths map[string]DiffStatus // Last path segment -> status
+	}{
+		{
+			name: "Reordered and modified elements are matched by key",
+			a:    containers(container("sidecar", "proxy:1"), container("app", "app:v1")),
+			b:    containers(container("app", "app:v2"), container("sidecar", "proxy:1")),
+			keys: KubernetesArrayKeys(),
+			wantPaths: map[string]DiffStatus{
+				"[name=sidecar]": StatusSame,
+				"[name=app]":     StatusModified,
+			},
+		},
+		{
+			name: "Added and deleted elements",
+			a:    containers(container("app", "app:v1"), container("old", "old:1")),
+			b:    containers(container("new", "new:1"), container("app", "app:v1")),
+			keys: KubernetesArrayKeys(),
+			wantPaths: map[string]DiffStatus{
+				"[name=app]": StatusSame,
+				"[name=old]": StatusDeleted,
+				"[name=new]": StatusAdded,
+			},
+		},
+		{
+			name: "More specific rule wins",
+			a:    containers(container("app", "app:v1")),
+			b:    containers(container("app", "app:v2")),
+			keys: []ArrayKeyRule{
+				{Path: "containers", Keys: []string{"name"}},
+				{Path: "spec.containers", Keys: []string{"image"}},
+			},
+			wantPaths: map[string]DiffStatus{
+				"[image=app:v1]": StatusDeleted,
+				"[image=app:v2]": StatusAdded,
+			},
+		},
+		{
+			name: "Duplicate keys fall back to value matching",
+			a:    containers(container("app", "app:v1"), container("app", "app:v2")),
+			b:    containers(container("app", "app:v2"), container("app", "app:v1")),
+			keys: KubernetesArrayKeys(),
+			wantPaths: map[string]DiffStatus{
+				"[0]": StatusSame,
+				"[1]": StatusSame,
+			},
+		},
+		{
+			name: "Arrays without rule fall back to value matching",
+			a:    containers(container("app", "app:v1")),
+			b:    containers(container("app", "app:v1")),
+			keys: nil,
+			wantPaths: map[string]DiffStatus{
+				"[0]": StatusSame,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine := NewDiffEngine(DiffOptions{ArrayDiffStrategy: ArrayStrategyKey, ArrayKeys: tt.keys})
+			a := &StructuredData{Type: TypeObject, Children: map[string]*StructuredData{"spec": tt.a}}
+			b := &StructuredData{Type: TypeObject, Children: map[string]*StructuredData{"spec": tt.b}}
+
+			result := engine.Compare(a, b)
+			arrayDiff := result.Children[0].Children[0]
+
+			got := make(map[string]DiffStatus)
+			for _, child := range arrayDiff.Children {
+				got[child.Path[len(child.Path)-1]] = child.Status
+			}
+			if len(got) != len(tt.wantPaths) {
+				t.Errorf("children = %v, want %v", got, tt.wantPaths)
+			}
+			for path, want := range tt.wantPaths {
+				if status, ok := got[path]; !ok || status != want {
+					t.Errorf("child %s status = %v (found %v), want %v", path, status, ok, want)
+				}
+			}
+		})
+	}
+}
+
 func TestDiffEngine_CompareObjects(t *testing.T) {
 	tests := []struct {
 		name   string
@@ -430,6 +537,129 @@ func TestDiffEngine_CompareObjects(t *testing.T) {
 	}
 }
 
+func TestDiffEngine_CompareObjects_KeyOrder(t *testing.T) {
+	a := &StructuredData{
+		Type: TypeObject,
+		Children: map[string]*StructuredData{
+			"zeta":  {Type: TypeNumber, Value: 1},
+			"alpha": {Type: TypeNumber, Value: 2},
+			"mid":   {Type: TypeNumber, Value: 3},
+		},
+		Keys: []string{"zeta", "alpha", "mid"},
+	}
+	b := &StructuredData{
+		Type: TypeObject,
+		Children: map[string]*StructuredData{
+			"new":   {Type: TypeNumber, Value: 0},
+			"zeta":  {Type: TypeNumber, Value: 1},
+			"alpha": {Type: TypeNumber, Value: 2},
+			"beta":  {Type: TypeNumber, Value: 4},
+			"mid":   {Type: TypeNumber, Value: 3},
+		},
+		Keys: []string{"new", "zeta", "alpha", "beta", "mid"},
+	}
+
+	tests := []struct {
+		name string
+		opts DiffOptions
+		want []string
+	}{
+		{
+			name: "Source order",
+			want: []string{"new", "zeta", "alpha", "beta", "mid"},
+		},
+		{
+			name: "Sorted keys",
+			opts: DiffOptions{SortKeys: true},
+			want: []string{"alpha", "beta", "mid", "new", "zeta"},
+		},
+		{
+			name: "Source order ignoring key case",
+			opts: DiffOptions{IgnoreKeyCase: true},
+			want: []string{"new", "zeta", "alpha", "beta", "mid"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := NewDiffEngine(tt.opts).Compare(a, b)
+
+			got := make([]string, 0, len(result.Children))
+			for _, child := range result.Children {
+				got = append(got, child.Path[len(child.Path)-1])
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("key order = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiffEngine_CompareComments(t *testing.T) {
+	withComments := func(value any, comments ...string) *StructuredData {
+		return &StructuredData{Type: TypeString, Value: value, Meta: &Metadata{Comments: comments}}
+	}
+
+	tests := []struct {
+		name           string
+		a              *StructuredData
+		b              *StructuredData
+		opts           DiffOptions
+		status         DiffStatus
+		commentChanged bool
+	}{
+		{
+			name:   "Comment change ignored by default",
+			a:      withComments("v", "old"),
+			b:      withComments("v", "new"),
+			status: StatusSame,
+		},
+		{
+			name:           "Comment change reported",
+			a:              withComments("v", "old"),
+			b:              withComments("v", "new"),
+			opts:           DiffOptions{CompareComments: true},
+			status:         StatusCommentChanged,
+			commentChanged: true,
+		},
+		{
+			name:           "Value and comment change",
+			a:              withComments("v1", "old"),
+			b:              withComments("v2", "new"),
+			opts:           DiffOptions{CompareComments: true},
+			status:         StatusModified,
+			commentChanged: true,
+		},
+		{
+			name:   "Same comments",
+			a:      withComments("v", "note"),
+			b:      withComments("v", "note"),
+			opts:   DiffOptions{CompareComments: true},
+			status: StatusSame,
+		},
+		{
+			name:   "Multiline string comments are not compared per line",
+			a:      withComments("line1\nline2", "old"),
+			b:      withComments("line1\nline2", "old"),
+			opts:   DiffOptions{CompareComments: true},
+			status: StatusSame,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := NewDiffEngine(tt.opts).Compare(tt.a, tt.b)
+			if result.Status != tt.status {
+				t.Errorf("Compare() status = %v, want %v", result.Status, tt.status)
+			}
+			gotChanged := result.Meta != nil && result.Meta.CommentChanged
+			if gotChanged != tt.commentChanged {
+				t.Errorf("Compare() CommentChanged = %v, want %v", gotChanged, tt.commentChanged)
+			}
+		})
+	}
+}
+
 func TestCompare_MultipleDocuments(t *testing.T) {
 	doc1a := &StructuredData{
 		Type: TypeObject,
@@ -658,3 +888,308 @@ func TestDiffEngine_MultilineStringComparison(t *testing.T) {
 		})
 	}
 }
+
+func TestDiffEngine_MultilineStringInsertion(t *testing.T) {
+	a := &StructuredData{Type: TypeString, Value: "set -e\nmake build\nmake test\nmake deploy"}
+	b := &StructuredData{Type: TypeString, Value: "set -e\nmake lint\nmake build\nmake test\nmake deploy"}
+
+	result := NewDiffEngine(DiffOptions{}).Compare(a, b)
+	if result.Status != StatusModified {
+		t.Fatalf("Compare() status = %v, want %v", result.Status, StatusModified)
+	}
+	if result.Meta.DiffCount != 1 {
+		t.Errorf("DiffCount = %d, want 1", result.Meta.DiffCount)
+	}
+
+	var changed []*DiffResult
+	for _, child := range result.Children {
+		if child.Status != StatusSame {
+			changed = append(changed, child)
+		}
+	}
+	if len(changed) != 1 || changed[0].Status != StatusAdded || changed[0].To.Value != "make lint" {
+		t.Errorf("expected a single added line, got %d changes", len(changed))
+	}
+}
+
+func TestDiffEngine_MultilineStringFullyChanged(t *testing.T) {
+	const lines = 4000
+	from := make([]string, lines)
+	to := make([]string, lines)
+	for i := range lines {
+		from[i] = fmt.Sprintf("old line %d", i)
+		to[i] = fmt.Sprintf("new line %d", i)
+	}
+	a := &StructuredData{Type: TypeString, Value: strings.Join(from, "\n")}
+	b := &StructuredData{Type: TypeString, Value: strings.Join(to, "\n")}
+
+	// Beyond the search limits the lines are compared by index
+	result := NewDiffEngine(DiffOptions{}).Compare(a, b)
+	if result.Status != StatusModified {
+		t.Fatalf("Compare() status = %v, want %v", result.Status, StatusModified)
+	}
+	if result.Meta.DiffCount != lines {
+		t.Errorf("DiffCount = %d, want %d", result.Meta.DiffCount, lines)
+	}
+	if len(result.Children) != lines || result.Children[lines-1].Path[0] != fmt.Sprintf("line %d", lines-1) {
+		t.Errorf("expected %d modified lines, got %d children", lines, len(result.Children))
+	}
+}
+
+func TestDiffEngine_CompareArraysOrdered(t *testing.T) {
+	strs := func(values ...string) *StructuredData {
+		elements := make([]*StructuredData, len(values))
+		for i, v := range values {
+			elements[i] = &StructuredData{Type: TypeString, Value: v}
+		}
+
+		return &StructuredData{Type: TypeArray, Elements: elements}
+	}
+
+	tests := []struct {
+		name string
+		a    *StructuredData
+		b    *StructuredData
+		want []string // status and last path segment of each child
+	}{
+		{
+			name: "Insertion at head",
+			a:    strs("a", "b", "c"),
+			b:    strs("x", "a", "b", "c"),
+			want: []string{"added [0]", "same [0]", "same [1]", "same [2]"},
+		},
+		{
+			name: "Deletion in the middle",
+			a:    strs("a", "b", "c"),
+			b:    strs("a", "c"),
+			want: []string{"same [0]", "deleted [1]", "same [2]"},
+		},
+		{
+			name: "Replacement is paired as modification",
+			a:    strs("a", "b", "c"),
+			b:    strs("a", "B", "c", "d"),
+			want: []string{"same [0]", "modified [1]", "same [2]", "added [3]"},
+		},
+		{
+			name: "Empty arrays",
+			a:    strs(),
+			b:    strs("a"),
+			want: []string{"added [0]"},
+		},
+		{
+			// Added elements index the second array, all others the first
+			name: "Indices of each side",
+			a:    strs("a", "x", "b"),
+			b:    strs("y", "a", "b", "z"),
+			want: []string{"added [0]", "same [0]", "deleted [1]", "same [2]", "added [3]"},
+		},
+	}
+
+	statusNames := map[DiffStatus]string{
+		StatusSame:     "same",
+		StatusModified: "modified",
+		StatusAdded:    "added",
+		StatusDeleted:  "deleted",
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := NewDiffEngine(DiffOptions{ArrayDiffStrategy: ArrayStrategyOrdered}).Compare(tt.a, tt.b)
+
+			got := make([]string, 0, len(result.Children))
+			for _, child := range result.Children {
+				got = append(got, statusNames[child.Status]+" "+child.Path[len(child.Path)-1])
+			}
+			if strings.Join(got, ", ") != strings.Join(tt.want, ", ") {
+				t.Errorf("children = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiffEngine_CompareArraysOrderedComparesPairsOnce(t *testing.T) {
+	counter := &countingNormalizer{}
+	opts := DiffOptions{ArrayDiffStrategy: ArrayStrategyOrdered, Normalizers: []Normalizer{counter}}
+	NewDiffEngine(opts).Compare(parseJSONDocument(t, `[1, 2, 3]`), parseJSONDocument(t, `[1, 2, 3]`))
+
+	// Each of the three pairs is normalized once per side
+	if counter.calls != 6 {
+		t.Errorf("Normalize() called %d times, want 6", counter.calls)
+	}
+}
+
+// countingNormalizer counts its calls and never applies.
+type countingNormalizer struct {
+	calls int
+}
+
+func (n *countingNormalizer) Normalize(*StructuredData) (*StructuredData, bool) {
+	n.calls++
+
+	return nil, false
+}
+
+func TestDiffEngine_DetectMoves(t *testing.T) {
+	obj := func(pairs ...any) *StructuredData {
+		data := &StructuredData{Type: TypeObject, Children: map[string]*StructuredData{}}
+		for i := 0; i < len(pairs); i += 2 {
+			key := pairs[i].(string) //nolint:forcetypeassert
+			data.Keys = append(data.Keys, key)
+			data.Children[key] = &StructuredData{Type: TypeNumber, Value: pairs[i+1]}
+		}
+
+		return data
+	}
+	strs := func(values ...string) *StructuredData {
+		elements := make([]*StructuredData, len(values))
+		for i, v := range values {
+			elements[i] = &StructuredData{Type: TypeString, Value: v}
+		}
+
+		return &StructuredData{Type: TypeArray, Elements: elements}
+	}
+
+	tests := []struct {
+		name     string
+		strategy ArrayDiffStrategy
+		a        *StructuredData
+		b        *StructuredData
+		want     []string // status, source and destination of each changed child
+	}{
+		{
+			name: "Renamed key with the same value",
+			a:    obj("timeout", 30, "retries", 3),
+			b:    obj("timeoutSeconds", 30, "retries", 3),
+			want: []string{"renamed timeout -> timeoutSeconds"},
+		},
+		{
+			name: "Renamed key with a different value stays delete and add",
+			a:    obj("timeout", 30),
+			b:    obj("timeoutSeconds", 60),
+			want: []string{"added  -> timeoutSeconds", "deleted  -> timeout"},
+		},
+		{
+			name: "Unrelated keys with the same value stay delete and add",
+			a:    obj("replicas", 1),
+			b:    obj("maxSurge", 1),
+			want: []string{"added  -> maxSurge", "deleted  -> replicas"},
+		},
+		{
+			name: "Renamed key with a similar object",
+			a: &StructuredData{Type: TypeObject, Keys: []string{"server"}, Children: map[string]*StructuredData{
+				"server": obj("host", 1, "port", 2, "tls", 3),
+			}},
+			b: &StructuredData{Type: TypeObject, Keys: []string{"backend"}, Children: map[string]*StructuredData{
+				"backend": obj("host", 1, "port", 2, "tls", 4),
+			}},
+			want: []string{"renamed server -> backend"},
+		},
+		{
+			name:     "Moved element under index strategy",
+			strategy: ArrayStrategyIndex,
+			a:        strs("a", "b", "c"),
+			b:        strs("c", "a", "b"),
+			want:     []string{"moved [2] -> [0]"},
+		},
+		{
+			name:     "Moved element under ordered strategy",
+			strategy: ArrayStrategyOrdered,
+			a:        strs("a", "b", "c", "d"),
+			b:        strs("b", "c", "d", "a"),
+			want:     []string{"moved [0] -> [3]"},
+		},
+	}
+
+	statusNames := map[DiffStatus]string{
+		StatusModified: "modified",
+		StatusAdded:    "added",
+		StatusDeleted:  "deleted",
+		StatusMoved:    "moved",
+		StatusRenamed:  "renamed",
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine := NewDiffEngine(DiffOptions{ArrayDiffStrategy: tt.strategy, DetectMoves: true})
+			result := engine.Compare(tt.a, tt.b)
+
+			var got []string
+			for _, child := range result.Children {
+				if child.Status == StatusSame {
+					continue
+				}
+				got = append(got, fmt.Sprintf("%s %s -> %s", statusNames[child.Status],
+					strings.Join(child.FromPath, "."), strings.Join(child.Path, ".")))
+			}
+			if strings.Join(got, ", ") != strings.Join(tt.want, ", ") {
+				t.Errorf("children = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiffEngine_PathFilters(t *testing.T) {
+	const from = `{"metadata": {"name": "web", "resourceVersion": "1"},
+		"spec": {"replicas": 1, "template": {"containers": [{"name": "web", "image": "nginx:1"}, {"name": "proxy", "image": "envoy:1"}]}},
+		"status": {"conditions": [{"type": "Ready", "lastTransitionTime": "t1"}]}}`
+	const to = `{"metadata": {"name": "web", "resourceVersion": "2"},
+		"spec": {"replicas": 2, "template": {"containers": [{"name": "web", "image": "nginx:2"}, {"name": "proxy", "image": "envoy:2"}]}},
+		"status": {"conditions": [{"type": "Ready", "lastTransitionTime": "t2"}], "replicas": 2}}`
+
+	tests := []struct {
+		name   string
+		ignore []string
+		only   []string
+		want   []string
+	}{
+		{
+			name:   "Ignore paths",
+			ignore: []string{"metadata.resourceVersion", "**.lastTransitionTime", "spec.template.containers[name=proxy]"},
+			want: []string{
+				"~ spec.replicas: 1 -> 2",
+				`~ spec.template.containers[0].image: "nginx:1" -> "nginx:2"`,
+				"+ status.replicas: 2",
+			},
+		},
+		{
+			name: "Only paths",
+			only: []string{"spec.template"},
+			want: []string{
+				`~ spec.template.containers[0].image: "nginx:1" -> "nginx:2"`,
+				`~ spec.template.containers[1].image: "envoy:1" -> "envoy:2"`,
+			},
+		},
+		{
+			name:   "Only and ignore paths",
+			ignore: []string{"status.conditions"},
+			only:   []string{"**.containers[*].image", "status"},
+			want: []string{
+				`~ spec.template.containers[0].image: "nginx:1" -> "nginx:2"`,
+				`~ spec.template.containers[1].image: "envoy:1" -> "envoy:2"`,
+				"+ status.replicas: 2",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := DiffOptions{ArrayDiffStrategy: ArrayStrategyValue}
+			for _, pattern := range tt.ignore {
+				opts.IgnorePaths = append(opts.IgnorePaths, MustParsePathPattern(pattern))
+			}
+			for _, pattern := range tt.only {
+				opts.OnlyPaths = append(opts.OnlyPaths, MustParsePathPattern(pattern))
+			}
+
+			result := NewDiffEngine(opts).Compare(parseJSONDocument(t, from), parseJSONDocument(t, to))
+
+			var buf strings.Builder
+			if err := (&PathsFormatter{ShowOnlyDiff: true}).Format(&buf, []*DiffResult{result}); err != nil {
+				t.Fatalf("Format() error = %v", err)
+			}
+			if got, want := buf.String(), strings.Join(tt.want, "\n")+"\n"; got != want {
+				t.Errorf("Compare() paths =\n%s\nwant:\n%s", got, want)
+			}
+		})
+	}
+}
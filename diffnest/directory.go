@@ -0,0 +1,326 @@
+package diffnest
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ErrInvalidGlob is returned for malformed -include and -exclude patterns.
+var ErrInvalidGlob = errors.New("invalid glob pattern")
+
+// structuredExtensions are the file extensions compared in directories when no
+// include patterns are given.
+//
+//nolint:gochecknoglobals
+var structuredExtensions = []string{".json", ".yaml", ".yml", ".toml"}
+
+// DirOptions selects and parses the files of a directory comparison.
+type DirOptions struct {
+	Include []string // Globs of files to compare (default: JSON, YAML and TOML files)
+	Exclude []string // Globs of files and directories to skip
+	Format1 string   // Format of every file in the first directory (default: by extension)
+	Format2 string   // Format of every file in the second directory (default: by extension)
+}
+
+// FilePair is a file of a directory comparison, by path relative to the compared
+// directories. From or To is empty when the file exists on one side only.
+type FilePair struct {
+	From string
+	To   string
+}
+
+// PairFiles walks both directories and pairs their files by relative path. Files
+// left over whose paths only differ in extension, such as app.json and app.yaml,
+// are paired as well when the match is unambiguous.
+func PairFiles(dir1, dir2 string, opts DirOptions) ([]FilePair, error) {
+	files1, err := listFiles(dir1, opts)
+	if err != nil {
+		return nil, err
+	}
+	files2, err := listFiles(dir2, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var pairs []FilePair
+	var rest1, rest2 []string
+	exists2 := make(map[string]bool, len(files2))
+	for _, file := range files2 {
+		exists2[file] = true
+	}
+	for _, file := range files1 {
+		if exists2[file] {
+			pairs = append(pairs, FilePair{From: file, To: file})
+			delete(exists2, file)
+		} else {
+			rest1 = append(rest1, file)
+		}
+	}
+	for _, file := range files2 {
+		if exists2[file] {
+			rest2 = append(rest2, file)
+		}
+	}
+
+	stems1, stems2 := groupByStem(rest1), groupByStem(rest2)
+	for _, file := range rest1 {
+		stem := fileStem(file)
+		if len(stems1[stem]) == 1 && len(stems2[stem]) == 1 {
+			pairs = append(pairs, FilePair{From: file, To: stems2[stem][0]})
+			delete(stems2, stem)
+		} else {
+			pairs = append(pairs, FilePair{From: file})
+		}
+	}
+	for _, file := range rest2 {
+		if _, ok := stems2[fileStem(file)]; ok {
+			pairs = append(pairs, FilePair{To: file})
+		}
+	}
+
+	sort.SliceStable(pairs, func(i, j int) bool {
+		return pairs[i].name() < pairs[j].name()
+	})
+
+	return pairs, nil
+}
+
+// name returns the path the pair is sorted and reported by.
+func (p FilePair) name() string {
+	if p.From != "" {
+		return p.From
+	}
+
+	return p.To
+}
+
+// listFiles returns the slash-separated paths of the selected files under dir, sorted.
+func listFiles(dir string, opts DirOptions) ([]string, error) {
+	var files []string
+
+	err := filepath.WalkDir(dir, func(file string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if file == dir {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, file)
+		if err != nil {
+			return fmt.Errorf("relative path of %s: %w", file, err)
+		}
+		rel = filepath.ToSlash(rel)
+
+		if matchesAny(opts.Exclude, rel) {
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+
+			return nil
+		}
+		if entry.IsDir() || !entry.Type().IsRegular() {
+			return nil
+		}
+
+		if len(opts.Include) > 0 {
+			if matchesAny(opts.Include, rel) {
+				files = append(files, rel)
+			}
+		} else if isStructuredFile(rel) {
+			files = append(files, rel)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("read directory %s: %w", dir, err)
+	}
+
+	return files, nil
+}
+
+// matchesAny reports whether the relative path matches one of the globs. Globs
+// without a slash match the base name in any directory.
+func matchesAny(globs []string, rel string) bool {
+	for _, glob := range globs {
+		name := rel
+		if !strings.Contains(glob, "/") {
+			name = path.Base(rel)
+		}
+		if ok, _ := path.Match(glob, name); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isStructuredFile(name string) bool {
+	ext := strings.ToLower(path.Ext(name))
+	for _, structured := range structuredExtensions {
+		if ext == structured {
+			return true
+		}
+	}
+
+	return false
+}
+
+func fileStem(name string) string {
+	return strings.TrimSuffix(name, path.Ext(name))
+}
+
+func groupByStem(files []string) map[string][]string {
+	stems := make(map[string][]string)
+	for _, file := range files {
+		stem := fileStem(file)
+		stems[stem] = append(stems[stem], file)
+	}
+
+	return stems
+}
+
+// DirController compares two directory trees file by file.
+type DirController struct {
+	dir1      string
+	dir2      string
+	dirOpts   DirOptions
+	diffOpts  DiffOptions
+	formatter Formatter
+	writer    io.Writer
+}
+
+// NewDirController creates a new directory controller.
+func NewDirController(dir1, dir2 string, dirOpts DirOptions, diffOpts DiffOptions, formatter Formatter, writer io.Writer) *DirController {
+	return &DirController{
+		dir1:      dir1,
+		dir2:      dir2,
+		dirOpts:   dirOpts,
+		diffOpts:  diffOpts,
+		formatter: formatter,
+		writer:    writer,
+	}
+}
+
+// dirSummary counts the outcome of the compared files.
+type dirSummary struct {
+	different, same, failed, only1, only2 int
+}
+
+// Run compares every file pair and returns whether any file differs or exists on
+// one side only. Each pair with output gets a header naming both files, and a
+// summary ends the output. Files that cannot be compared do not stop the others;
+// their errors are returned together.
+func (c *DirController) Run() (bool, error) {
+	pairs, err := PairFiles(c.dir1, c.dir2, c.dirOpts)
+	if err != nil {
+		return false, &IOError{Op: "reading directories", Err: err}
+	}
+
+	writer := &errorWriter{w: c.writer}
+	var summary dirSummary
+	var errs []error
+
+	for _, pair := range pairs {
+		switch {
+		case pair.To == "":
+			summary.only1++
+			fmt.Fprintf(writer, "Only in %s: %s\n", c.dir1, pair.From)
+		case pair.From == "":
+			summary.only2++
+			fmt.Fprintf(writer, "Only in %s: %s\n", c.dir2, pair.To)
+		default:
+			different, err := c.comparePair(writer, pair)
+			switch {
+			case err != nil:
+				summary.failed++
+				errs = append(errs, fmt.Errorf("%s: %w", pair.From, err))
+			case different:
+				summary.different++
+			default:
+				summary.same++
+			}
+		}
+		if writer.err != nil {
+			return false, &IOError{Op: "writing output", Err: writer.err}
+		}
+	}
+
+	fmt.Fprintln(writer, c.summaryLine(summary))
+	if writer.err != nil {
+		return false, &IOError{Op: "writing output", Err: writer.err}
+	}
+
+	return summary.different+summary.only1+summary.only2 > 0, errors.Join(errs...)
+}
+
+// comparePair compares one file pair with a Controller and writes its output
+// after a header.
+func (c *DirController) comparePair(w io.Writer, pair FilePair) (bool, error) {
+	file1 := filepath.Join(c.dir1, filepath.FromSlash(pair.From))
+	file2 := filepath.Join(c.dir2, filepath.FromSlash(pair.To))
+
+	reader1, err := os.Open(file1)
+	if err != nil {
+		return false, &IOError{Op: "opening first file", Err: err}
+	}
+	defer reader1.Close()
+
+	reader2, err := os.Open(file2)
+	if err != nil {
+		return false, &IOError{Op: "opening second file", Err: err}
+	}
+	defer reader2.Close()
+
+	format1, format2 := c.dirOpts.Format1, c.dirOpts.Format2
+	if format1 == "" {
+		format1 = DetectFormatFromFilename(pair.From)
+	}
+	if format2 == "" {
+		format2 = DetectFormatFromFilename(pair.To)
+	}
+
+	var output bytes.Buffer
+	different, err := NewController(reader1, reader2, format1, format2, c.diffOpts, c.formatter, &output).Run()
+	if err != nil {
+		return false, err
+	}
+
+	if output.Len() > 0 {
+		fmt.Fprintf(w, "diffnest %s %s\n", filepath.ToSlash(file1), filepath.ToSlash(file2))
+		if _, err := output.WriteTo(w); err != nil {
+			return false, &IOError{Op: "writing output", Err: err}
+		}
+	}
+
+	return different, nil
+}
+
+func (c *DirController) summaryLine(s dirSummary) string {
+	parts := []string{
+		fmt.Sprintf("%d different", s.different),
+		fmt.Sprintf("%d same", s.same),
+	}
+	if s.failed > 0 {
+		parts = append(parts, fmt.Sprintf("%d failed", s.failed))
+	}
+	if s.only1 > 0 {
+		parts = append(parts, fmt.Sprintf("%d only in %s", s.only1, c.dir1))
+	}
+	if s.only2 > 0 {
+		parts = append(parts, fmt.Sprintf("%d only in %s", s.only2, c.dir2))
+	}
+
+	total := s.different + s.same + s.failed + s.only1 + s.only2
+
+	return fmt.Sprintf("%s: %s", pluralize(total, "file"), strings.Join(parts, ", "))
+}
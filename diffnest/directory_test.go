@@ -0,0 +1,133 @@
+package diffnest
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// writeTree creates files, keyed by slash-separated path, under a new temporary directory.
+func writeTree(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	for name, content := range files {
+		file := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(file, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return dir
+}
+
+func TestPairFiles(t *testing.T) {
+	dir1 := writeTree(t, map[string]string{
+		"app.yaml":         "",
+		"db/config.json":   "",
+		"only.yaml":        "",
+		"secrets/key.yaml": "",
+		"README.md":        "",
+		"ambiguous.json":   "",
+		"ambiguous.yaml":   "",
+	})
+	dir2 := writeTree(t, map[string]string{
+		"app.json":         "",
+		"db/config.json":   "",
+		"new.toml":         "",
+		"secrets/key.yaml": "",
+		"ambiguous.toml":   "",
+	})
+
+	tests := []struct {
+		name string
+		opts DirOptions
+		want []FilePair
+	}{
+		{
+			name: "Structured files",
+			want: []FilePair{
+				{From: "ambiguous.json"},
+				{To: "ambiguous.toml"},
+				{From: "ambiguous.yaml"},
+				{From: "app.yaml", To: "app.json"},
+				{From: "db/config.json", To: "db/config.json"},
+				{To: "new.toml"},
+				{From: "only.yaml"},
+				{From: "secrets/key.yaml", To: "secrets/key.yaml"},
+			},
+		},
+		{
+			name: "Include and exclude",
+			opts: DirOptions{Include: []string{"*.json", "db/*"}, Exclude: []string{"ambiguous.*"}},
+			want: []FilePair{
+				{To: "app.json"},
+				{From: "db/config.json", To: "db/config.json"},
+			},
+		},
+		{
+			name: "Excluded directory",
+			opts: DirOptions{Exclude: []string{"secrets", "ambiguous.*", "new.toml"}},
+			want: []FilePair{
+				{From: "app.yaml", To: "app.json"},
+				{From: "db/config.json", To: "db/config.json"},
+				{From: "only.yaml"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := PairFiles(dir1, dir2, tt.opts)
+			if err != nil {
+				t.Fatalf("PairFiles() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("PairFiles() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDirController_Run(t *testing.T) {
+	dir1 := writeTree(t, map[string]string{
+		"app.yaml":       "name: app\nport: 80\n",
+		"same.yaml":      "a: 1\n",
+		"only.yaml":      "a: 1\n",
+		"broken/x.json":  `{"a":`,
+		"broken/ok.json": `{"a": 1}`,
+	})
+	dir2 := writeTree(t, map[string]string{
+		"app.json":       `{"name": "app", "port": 8080}`,
+		"same.yaml":      "a: 1\n",
+		"broken/x.json":  `{"a": 1}`,
+		"broken/ok.json": `{"a": 1}`,
+	})
+
+	var output strings.Builder
+	controller := NewDirController(dir1, dir2, DirOptions{}, DiffOptions{}, &PathsFormatter{ShowOnlyDiff: true}, &output)
+
+	hasDifferences, err := controller.Run()
+	if !hasDifferences {
+		t.Error("Run() hasDifferences = false, want true")
+	}
+
+	var parseErr *ParseError
+	if !errors.As(err, &parseErr) || !strings.Contains(err.Error(), "broken/x.json") {
+		t.Errorf("Run() error = %v, want *ParseError for broken/x.json", err)
+	}
+
+	want := "diffnest " + filepath.ToSlash(filepath.Join(dir1, "app.yaml")) + " " + filepath.ToSlash(filepath.Join(dir2, "app.json")) + "\n" +
+		"~ port: 80 -> 8080\n" +
+		"Only in " + dir1 + ": only.yaml\n" +
+		"5 files: 1 different, 2 same, 1 failed, 1 only in " + dir1 + "\n"
+	if got := output.String(); got != want {
+		t.Errorf("Run() output =\n%s\nwant:\n%s", got, want)
+	}
+}
@@ -0,0 +1,410 @@
+package diffnest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/goccy/go-yaml"
+)
+
+// ErrUnencodable is returned when a document cannot be represented in the output format.
+var ErrUnencodable = errors.New("cannot encode document")
+
+// EncodeWithFormat writes documents to writer in the specified format.
+// Object keys are written in source order.
+func EncodeWithFormat(writer io.Writer, docs []*StructuredData, format string) error {
+	var encode func(io.Writer, *StructuredData) error
+	switch format {
+	case FormatJSON:
+		encode = encodeJSON
+	case FormatYAML:
+		encode = encodeYAML
+	case FormatTOML:
+		if len(docs) > 1 {
+			return fmt.Errorf("%w: TOML holds a single document, got %d", ErrUnencodable, len(docs))
+		}
+		encode = encodeTOML
+	default:
+		return fmt.Errorf("%w: %s", ErrUnsupportedFormat, format)
+	}
+
+	for i, doc := range docs {
+		if i > 0 && format == FormatYAML {
+			if _, err := io.WriteString(writer, "---\n"); err != nil {
+				return fmt.Errorf("write document separator: %w", err)
+			}
+		}
+		if err := encode(writer, doc); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func encodeJSON(w io.Writer, doc *StructuredData) error {
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, []byte(jsonValue(doc)), "", "  "); err != nil {
+		return fmt.Errorf("indent JSON: %w", err)
+	}
+	buf.WriteByte('\n')
+
+	if _, err := buf.WriteTo(w); err != nil {
+		return fmt.Errorf("write JSON: %w", err)
+	}
+
+	return nil
+}
+
+func encodeYAML(w io.Writer, doc *StructuredData) error {
+	comments := yaml.CommentMap{}
+	if err := collectYAMLComments(doc, "$", comments); err != nil {
+		return err
+	}
+
+	options := []yaml.EncodeOption{yaml.IndentSequence(true), yaml.UseLiteralStyleIfMultiline(true)}
+	if len(comments) > 0 {
+		options = append(options, yaml.WithComment(comments))
+	}
+
+	out, err := yaml.MarshalWithOptions(yamlValue(doc), options...)
+	if err != nil {
+		return fmt.Errorf("marshal YAML: %w", err)
+	}
+
+	if _, err := w.Write(out); err != nil {
+		return fmt.Errorf("write YAML: %w", err)
+	}
+
+	return nil
+}
+
+func encodeTOML(w io.Writer, doc *StructuredData) error {
+	if doc.Type != TypeObject {
+		return fmt.Errorf("%w: TOML document must be a table", ErrUnencodable)
+	}
+
+	var b strings.Builder
+	if err := writeTOMLTable(&b, doc, nil, false); err != nil {
+		return err
+	}
+
+	if _, err := io.WriteString(w, b.String()); err != nil {
+		return fmt.Errorf("write TOML: %w", err)
+	}
+
+	return nil
+}
+
+// collectYAMLComments adds the comments of data and its descendants to comments,
+// keyed by their YAML path, so that the comments of a parsed document are written back.
+func collectYAMLComments(data *StructuredData, path string, comments yaml.CommentMap) error {
+	if data.Meta != nil && len(data.Meta.yamlComments) > 0 {
+		if _, err := yaml.PathString(path); err != nil {
+			return fmt.Errorf("%w: comments at %s: %w", ErrUnencodable, path, err)
+		}
+		comments[path] = data.Meta.yamlComments
+	}
+
+	switch data.Type {
+	case TypeObject:
+		for _, key := range data.ChildKeys() {
+			if err := collectYAMLComments(data.Children[key], path+"."+yamlPathKey(key), comments); err != nil {
+				return err
+			}
+		}
+	case TypeArray:
+		for i, elem := range data.Elements {
+			if err := collectYAMLComments(elem, fmt.Sprintf("%s[%d]", path, i), comments); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// yamlPathKey returns key as a segment of a YAML path, quoted like the YAML parser
+// quotes keys holding path characters.
+func yamlPathKey(key string) string {
+	if strings.ContainsAny(key, "$*.[]") {
+		return "'" + key + "'"
+	}
+
+	return key
+}
+
+// yamlValue converts data into values the YAML encoder understands, keeping key order.
+func yamlValue(data *StructuredData) any {
+	switch data.Type {
+	case TypeObject:
+		slice := make(yaml.MapSlice, 0, len(data.Children))
+		for _, key := range data.ChildKeys() {
+			slice = append(slice, yaml.MapItem{Key: key, Value: yamlValue(data.Children[key])})
+		}
+
+		return slice
+	case TypeArray:
+		elements := make([]any, len(data.Elements))
+		for i, elem := range data.Elements {
+			elements[i] = yamlValue(elem)
+		}
+
+		return elements
+	case TypeNull:
+		return nil
+	default:
+		if number, ok := data.Value.(json.Number); ok {
+			return yamlNumber(number)
+		}
+
+		return encodableValue(data.Value)
+	}
+}
+
+// encodableValue turns integral floats, which is how JSON numbers are parsed, back into integers
+// so that they are not written as floats in YAML and TOML.
+func encodableValue(value any) any {
+	if f, ok := value.(float64); ok && f == math.Trunc(f) && math.Abs(f) < 1<<53 {
+		return int64(f)
+	}
+
+	return value
+}
+
+// writeTOMLTable writes the keys of table, values first and then tables and arrays
+// of tables, each in source order. path is the key of table, written as its header
+// unless table holds nothing but tables. arrayElement writes the header of an
+// element of an array of tables.
+func writeTOMLTable(b *strings.Builder, table *StructuredData, path []string, arrayElement bool) error {
+	keys := table.ChildKeys()
+
+	var values, tables []string
+	for _, key := range keys {
+		child := table.Children[key]
+		if (child.Type == TypeObject) || isTOMLArrayOfTables(child) {
+			tables = append(tables, key)
+		} else {
+			values = append(values, key)
+		}
+	}
+
+	if len(path) > 0 && (arrayElement || len(values) > 0 || len(tables) == 0) {
+		if b.Len() > 0 {
+			b.WriteString("\n")
+		}
+		if arrayElement {
+			fmt.Fprintf(b, "[[%s]]\n", tomlPath(path))
+		} else {
+			fmt.Fprintf(b, "[%s]\n", tomlPath(path))
+		}
+	}
+
+	for _, key := range values {
+		childPath := append(slices.Clone(path), key)
+		value, err := tomlInlineValue(table.Children[key], childPath)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(b, "%s = %s\n", tomlKey(key), value)
+	}
+
+	for _, key := range tables {
+		child := table.Children[key]
+		childPath := append(slices.Clone(path), key)
+		if child.Type == TypeObject {
+			if err := writeTOMLTable(b, child, childPath, false); err != nil {
+				return err
+			}
+
+			continue
+		}
+		for _, elem := range child.Elements {
+			if err := writeTOMLTable(b, elem, childPath, true); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// isTOMLArrayOfTables reports whether data is written as an array of tables.
+func isTOMLArrayOfTables(data *StructuredData) bool {
+	if data.Type != TypeArray || len(data.Elements) == 0 {
+		return false
+	}
+
+	for _, elem := range data.Elements {
+		if elem.Type != TypeObject {
+			return false
+		}
+	}
+
+	return true
+}
+
+// tomlInlineValue formats data as a TOML value on a single line. Objects become inline tables.
+func tomlInlineValue(data *StructuredData, path []string) (string, error) {
+	switch data.Type {
+	case TypeObject:
+		parts := make([]string, 0, len(data.Children))
+		for _, key := range data.ChildKeys() {
+			value, err := tomlInlineValue(data.Children[key], append(slices.Clone(path), key))
+			if err != nil {
+				return "", err
+			}
+			parts = append(parts, tomlKey(key)+" = "+value)
+		}
+		if len(parts) == 0 {
+			return "{}", nil
+		}
+
+		return "{ " + strings.Join(parts, ", ") + " }", nil
+	case TypeArray:
+		parts := make([]string, len(data.Elements))
+		for i, elem := range data.Elements {
+			value, err := tomlInlineValue(elem, append(slices.Clone(path), strconv.Itoa(i)))
+			if err != nil {
+				return "", err
+			}
+			parts[i] = value
+		}
+
+		return "[" + strings.Join(parts, ", ") + "]", nil
+	case TypeString:
+		if data.Meta != nil && data.Meta.Datetime {
+			return fmt.Sprint(data.Value), nil
+		}
+
+		return tomlString(fmt.Sprint(data.Value)), nil
+	case TypeBool:
+		return fmt.Sprint(data.Value), nil
+	case TypeNumber:
+		number, err := tomlNumber(data.Value)
+		if err != nil {
+			return "", fmt.Errorf("%w at %s", err, jsonPointer(path))
+		}
+
+		return number, nil
+	case TypeNull:
+	}
+
+	return "", fmt.Errorf("%w: TOML has no null value at %s", ErrUnencodable, jsonPointer(path))
+}
+
+// tomlNumber formats a number as a TOML integer or float. Numbers TOML cannot hold
+// exactly, like integers beyond 64 bits, are an error rather than rounded.
+func tomlNumber(value any) (string, error) {
+	switch v := value.(type) {
+	case json.Number:
+		literal := string(v)
+		if !strings.ContainsAny(literal, ".eE") {
+			if _, err := strconv.ParseInt(literal, 10, 64); err != nil {
+				return "", fmt.Errorf("%w: integer %s exceeds 64 bits", ErrUnencodable, literal)
+			}
+
+			return literal, nil
+		}
+		if f, err := strconv.ParseFloat(literal, 64); err != nil || math.IsInf(f, 0) {
+			return "", fmt.Errorf("%w: float %s is out of range", ErrUnencodable, literal)
+		}
+
+		return literal, nil
+	case float32:
+		return tomlFloat(float64(v), 32), nil
+	case float64:
+		return tomlFloat(v, 64), nil
+	case uint64:
+		if v > math.MaxInt64 {
+			return "", fmt.Errorf("%w: integer %d exceeds 64 bits", ErrUnencodable, v)
+		}
+	case uint:
+		if uint64(v) > math.MaxInt64 {
+			return "", fmt.Errorf("%w: integer %d exceeds 64 bits", ErrUnencodable, v)
+		}
+	}
+
+	if i, ok := toInt64(value); ok {
+		return strconv.FormatInt(i, 10), nil
+	}
+
+	return "", fmt.Errorf("%w: unsupported number %v", ErrUnencodable, value)
+}
+
+func tomlFloat(f float64, bitSize int) string {
+	switch {
+	case math.IsNaN(f):
+		return "nan"
+	case math.IsInf(f, 1):
+		return "inf"
+	case math.IsInf(f, -1):
+		return "-inf"
+	}
+
+	literal := strconv.FormatFloat(f, 'g', -1, bitSize)
+	if !strings.ContainsAny(literal, ".e") {
+		literal += ".0"
+	}
+
+	return literal
+}
+
+// tomlPath formats the keys of a table header.
+func tomlPath(path []string) string {
+	keys := make([]string, len(path))
+	for i, key := range path {
+		keys[i] = tomlKey(key)
+	}
+
+	return strings.Join(keys, ".")
+}
+
+// tomlKey returns key bare when TOML allows it, and quoted otherwise.
+func tomlKey(key string) string {
+	if key != "" && strings.Trim(key, "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789_-") == "" {
+		return key
+	}
+
+	return tomlString(key)
+}
+
+// tomlString returns s as a TOML basic string.
+func tomlString(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			b.WriteString(`\"`)
+		case '\\':
+			b.WriteString(`\\`)
+		case '\b':
+			b.WriteString(`\b`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\f':
+			b.WriteString(`\f`)
+		case '\r':
+			b.WriteString(`\r`)
+		default:
+			if r < 0x20 || r == 0x7f {
+				fmt.Fprintf(&b, `\u%04X`, r)
+			} else {
+				b.WriteRune(r)
+			}
+		}
+	}
+	b.WriteByte('"')
+
+	return b.String()
+}
@@ -0,0 +1,73 @@
+package diffnest
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestEncodeWithFormat(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		format  string
+		want    string
+		wantErr error
+	}{
+		{
+			name:    "JSON keeps key order",
+			content: "b: 1\na: [x, 2.5]\n",
+			format:  FormatJSON,
+			want:    "{\n  \"b\": 1,\n  \"a\": [\n    \"x\",\n    2.5\n  ]\n}\n",
+		},
+		{
+			name:    "YAML",
+			content: `{"name": "app", "ports": [80, 443], "script": "a\nb", "empty": null}`,
+			format:  FormatYAML,
+			want:    "name: app\nports:\n  - 80\n  - 443\nscript: |-\n  a\n  b\nempty: null\n",
+		},
+		{
+			name:    "TOML",
+			content: `{"title": "x", "server": {"port": 80}}`,
+			format:  FormatTOML,
+			want:    "title = \"x\"\n\n[server]\nport = 80\n",
+		},
+		{
+			name:    "TOML rejects null",
+			content: `{"server": {"host": null}}`,
+			format:  FormatTOML,
+			wantErr: ErrUnencodable,
+		},
+		{
+			name:    "Unsupported format",
+			content: `{}`,
+			format:  "xml",
+			wantErr: ErrUnsupportedFormat,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			docs, err := ParseWithFormat(strings.NewReader(tt.content), FormatYAML)
+			if err != nil {
+				t.Fatalf("ParseWithFormat() error = %v", err)
+			}
+
+			var buf strings.Builder
+			err = EncodeWithFormat(&buf, docs, tt.format)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("EncodeWithFormat() error = %v, want %v", err, tt.wantErr)
+				}
+
+				return
+			}
+			if err != nil {
+				t.Fatalf("EncodeWithFormat() error = %v", err)
+			}
+			if got := buf.String(); got != tt.want {
+				t.Errorf("EncodeWithFormat() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
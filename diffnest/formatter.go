@@ -1,8 +1,14 @@
 package diffnest
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"math"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
 )
 
@@ -20,10 +26,26 @@ type UnifiedFormatter struct {
 	ShowOnlyDiff bool
 	Verbose      bool
 	ContextLines int
+	SortKeys     bool // Print keys of added/deleted objects alphabetically instead of by source order
+	Color        bool // Color lines with ANSI escape sequences
+	ShowDelta    bool // Show the difference next to modified numbers, like "(+0.0003, +2.1%)"
 }
 
 // Format formats diff results.
 func (f *UnifiedFormatter) Format(w io.Writer, results []*DiffResult) error {
+	if !f.Color {
+		return f.format(w, results)
+	}
+
+	cw := &colorWriter{w: w}
+	if err := f.format(cw, results); err != nil {
+		return err
+	}
+
+	return cw.Flush()
+}
+
+func (f *UnifiedFormatter) format(w io.Writer, results []*DiffResult) error {
 	needsSeparator := false
 
 	for _, result := range results {
@@ -109,6 +131,10 @@ func (f *UnifiedFormatter) formatDiff(w io.Writer, diff *DiffResult, indent stri
 		return nil
 	}
 
+	if err := f.formatCommentDiff(w, diff, indent); err != nil {
+		return err
+	}
+
 	switch diff.Status {
 	case StatusSame:
 		return f.formatSameDiff(w, diff, indent)
@@ -118,13 +144,107 @@ func (f *UnifiedFormatter) formatDiff(w io.Writer, diff *DiffResult, indent stri
 		return f.formatDeleted(w, diff, indent)
 	case StatusAdded:
 		return f.formatAdded(w, diff, indent)
+	case StatusCommentChanged:
+		// Only the comments differ, which formatCommentDiff has already written
+		return nil
+	case StatusMoved, StatusRenamed:
+		return f.formatMoved(w, diff, indent)
+	case StatusTypeChanged:
+		return f.formatTypeChanged(w, diff, indent)
+	}
+
+	return nil
+}
+
+// formatTypeChanged formats a value equal only after type coercion as unchanged,
+// noting the change of its type.
+func (f *UnifiedFormatter) formatTypeChanged(w io.Writer, diff *DiffResult, indent string) error {
+	label := ""
+	if len(diff.Path) > 0 {
+		label = diff.Path[len(diff.Path)-1] + ": "
+		if strings.HasPrefix(label, "[") {
+			label = "- "
+		}
+	}
+
+	if _, err := fmt.Fprintf(w, "  %s%s%s  (%s)\n", indent, label, f.formatValue(diff.To), typeChange(diff)); err != nil {
+		return fmt.Errorf("write type change: %w", err)
+	}
+
+	return nil
+}
+
+// typeChange describes the type change of a StatusTypeChanged value, like "type: string -> number".
+func typeChange(diff *DiffResult) string {
+	return fmt.Sprintf("type: %s -> %s", diff.From.Type, diff.To.Type)
+}
+
+// formatMoved formats a moved array element or renamed object key with a "~" marker,
+// followed by the differences between the source and destination values.
+func (f *UnifiedFormatter) formatMoved(w io.Writer, diff *DiffResult, indent string) error {
+	verb := "moved"
+	if diff.Status == StatusRenamed {
+		verb = "renamed"
+	}
+
+	if _, err := fmt.Fprintf(w, "~ %s%s from %s to %s: %s\n",
+		indent, verb, lastSegment(diff.FromPath), lastSegment(diff.Path), f.formatValue(diff.To)); err != nil {
+		return fmt.Errorf("write %s value: %w", verb, err)
+	}
+
+	if len(diff.Children) == 0 {
+		return nil
+	}
+
+	return f.formatModifiedContainer(w, diff, indent+"  ")
+}
+
+// lastSegment returns the last element of path.
+func lastSegment(path []string) string {
+	if len(path) == 0 {
+		return ""
+	}
+
+	return path[len(path)-1]
+}
+
+// formatCommentDiff formats changed comments with a "#" marker so they stand apart from value changes.
+func (f *UnifiedFormatter) formatCommentDiff(w io.Writer, diff *DiffResult, indent string) error {
+	if diff.Meta == nil || !diff.Meta.CommentChanged {
+		return nil
+	}
+
+	if len(diff.Path) > 0 {
+		if _, err := fmt.Fprintf(w, "# %s%s:\n", indent, diff.Path[len(diff.Path)-1]); err != nil {
+			return fmt.Errorf("write comment key: %w", err)
+		}
+	}
+
+	for _, comment := range commentsOf(diff.From) {
+		if _, err := fmt.Fprintf(w, "# %s  - %s\n", indent, comment); err != nil {
+			return fmt.Errorf("write deleted comment: %w", err)
+		}
+	}
+	for _, comment := range commentsOf(diff.To) {
+		if _, err := fmt.Fprintf(w, "# %s  + %s\n", indent, comment); err != nil {
+			return fmt.Errorf("write added comment: %w", err)
+		}
 	}
 
 	return nil
 }
 
+// commentsOf returns the comments attached to data.
+func commentsOf(data *StructuredData) []string {
+	if data == nil || data.Meta == nil {
+		return nil
+	}
+
+	return data.Meta.Comments
+}
+
 func (f *UnifiedFormatter) shouldSkipUnchanged(diff *DiffResult) bool {
-	return f.ShowOnlyDiff && diff.Status == StatusSame && f.ContextLines < 0
+	return f.ShowOnlyDiff && diff.Status == StatusSame && f.ContextLines < 0 && !hasTypeChanges(diff)
 }
 
 func (f *UnifiedFormatter) getPathString(path []string) string {
@@ -146,16 +266,16 @@ func (f *UnifiedFormatter) formatSameDiff(w io.Writer, diff *DiffResult, indent
 					return fmt.Errorf("write array marker: %w", err)
 				}
 
-				return f.formatChildren(w, diff.Children, indent+"  ")
+				return f.formatSameChildren(w, diff, indent+"  ")
 			}
 			if _, err := fmt.Fprintf(w, "  %s%s:\n", indent, key); err != nil {
 				return fmt.Errorf("write object key: %w", err)
 			}
 
-			return f.formatChildren(w, diff.Children, indent+"  ")
+			return f.formatSameChildren(w, diff, indent+"  ")
 		}
 
-		return f.formatChildren(w, diff.Children, indent)
+		return f.formatSameChildren(w, diff, indent)
 	}
 
 	if len(diff.Path) > 0 {
@@ -174,6 +294,16 @@ func (f *UnifiedFormatter) formatSameDiff(w io.Writer, diff *DiffResult, indent
 	return nil
 }
 
+// formatSameChildren formats the children of an unchanged container. Type changes
+// inside it are shown like modifications, with the same context.
+func (f *UnifiedFormatter) formatSameChildren(w io.Writer, diff *DiffResult, indent string) error {
+	if hasTypeChanges(diff) {
+		return f.formatModifiedContainer(w, diff, indent)
+	}
+
+	return f.formatChildren(w, diff.Children, indent)
+}
+
 func (f *UnifiedFormatter) formatModifiedDiff(w io.Writer, diff *DiffResult, indent string) error {
 	if len(diff.Children) == 0 {
 		return f.formatModifiedPrimitive(w, diff, indent)
@@ -204,19 +334,23 @@ func (f *UnifiedFormatter) formatModifiedDiff(w io.Writer, diff *DiffResult, ind
 
 func (f *UnifiedFormatter) formatModifiedPrimitive(w io.Writer, diff *DiffResult, indent string) error {
 	if len(diff.Path) > 0 {
+		from, to := f.formatChangedValues(diff.From, diff.To)
+		if delta, ok := numericDelta(diff.From, diff.To); ok && f.ShowDelta {
+			to += "  (" + delta + ")"
+		}
 		key := diff.Path[len(diff.Path)-1]
 		if strings.HasPrefix(key, "[") && strings.HasSuffix(key, "]") {
-			if _, err := fmt.Fprintf(w, "- %s- %s\n", indent, f.formatValue(diff.From)); err != nil {
+			if _, err := fmt.Fprintf(w, "- %s- %s\n", indent, from); err != nil {
 				return fmt.Errorf("write deleted array element: %w", err)
 			}
-			if _, err := fmt.Fprintf(w, "+ %s- %s\n", indent, f.formatValue(diff.To)); err != nil {
+			if _, err := fmt.Fprintf(w, "+ %s- %s\n", indent, to); err != nil {
 				return fmt.Errorf("write added array element: %w", err)
 			}
 		} else {
-			if _, err := fmt.Fprintf(w, "- %s%s: %s\n", indent, key, f.formatValue(diff.From)); err != nil {
+			if _, err := fmt.Fprintf(w, "- %s%s: %s\n", indent, key, from); err != nil {
 				return fmt.Errorf("write deleted value: %w", err)
 			}
-			if _, err := fmt.Fprintf(w, "+ %s%s: %s\n", indent, key, f.formatValue(diff.To)); err != nil {
+			if _, err := fmt.Fprintf(w, "+ %s%s: %s\n", indent, key, to); err != nil {
 				return fmt.Errorf("write added value: %w", err)
 			}
 		}
@@ -225,6 +359,50 @@ func (f *UnifiedFormatter) formatModifiedPrimitive(w io.Writer, diff *DiffResult
 	return nil
 }
 
+// formatChangedValues formats the old and new value of a modification.
+// Strings sharing some words have their changed spans marked.
+func (f *UnifiedFormatter) formatChangedValues(from, to *StructuredData) (string, string) {
+	if from == nil || to == nil || from.Type != TypeString || to.Type != TypeString {
+		return f.formatValue(from), f.formatValue(to)
+	}
+
+	fromSpans, toSpans, ok := inlineDiff(fmt.Sprint(from.Value), fmt.Sprint(to.Value))
+	if !ok {
+		return f.formatValue(from), f.formatValue(to)
+	}
+
+	return f.renderDeletedSpans(fromSpans, f.formatValue(from) != fmt.Sprint(from.Value)),
+		f.renderAddedSpans(toSpans, f.formatValue(to) != fmt.Sprint(to.Value))
+}
+
+// formatChangedLines formats the old and new text of a modified line in a multiline string.
+func (f *UnifiedFormatter) formatChangedLines(from, to string) (string, string) {
+	fromSpans, toSpans, ok := inlineDiff(from, to)
+	if !ok {
+		return from, to
+	}
+
+	return f.renderDeletedSpans(fromSpans, false), f.renderAddedSpans(toSpans, false)
+}
+
+// renderDeletedSpans marks the changed spans of an old value, inverted with colors and as [-text-] without.
+func (f *UnifiedFormatter) renderDeletedSpans(spans []inlineSpan, quote bool) string {
+	if f.Color {
+		return renderSpans(spans, ansiInvert, ansiRevert, quote)
+	}
+
+	return renderSpans(spans, deletedSpanStart, deletedSpanEnd, quote)
+}
+
+// renderAddedSpans marks the changed spans of a new value, inverted with colors and as {+text+} without.
+func (f *UnifiedFormatter) renderAddedSpans(spans []inlineSpan, quote bool) string {
+	if f.Color {
+		return renderSpans(spans, ansiInvert, ansiRevert, quote)
+	}
+
+	return renderSpans(spans, addedSpanStart, addedSpanEnd, quote)
+}
+
 func (f *UnifiedFormatter) isMultilineStringDiff(diff *DiffResult) bool {
 	return diff.From != nil && diff.From.Type == TypeString &&
 		diff.To != nil && diff.To.Type == TypeString
@@ -348,7 +526,8 @@ func (f *UnifiedFormatter) formatArrayElement(w io.Writer, data *StructuredData,
 func (f *UnifiedFormatter) formatStructure(w io.Writer, data *StructuredData, indent, prefix string) error {
 	switch data.Type {
 	case TypeObject:
-		for key, child := range data.Children {
+		for _, key := range f.objectKeys(data) {
+			child := data.Children[key]
 			switch child.Type {
 			case TypeObject:
 				if _, err := fmt.Fprintf(w, "%s%s%s:\n", prefix, indent, key); err != nil {
@@ -403,6 +582,16 @@ func (f *UnifiedFormatter) formatStructure(w io.Writer, data *StructuredData, in
 	return nil
 }
 
+// objectKeys returns the keys of an object in display order.
+func (f *UnifiedFormatter) objectKeys(data *StructuredData) []string {
+	keys := data.ChildKeys()
+	if f.SortKeys {
+		sort.Strings(keys)
+	}
+
+	return keys
+}
+
 func (f *UnifiedFormatter) formatValue(data *StructuredData) string {
 	if data == nil {
 		return valueNull
@@ -411,7 +600,13 @@ func (f *UnifiedFormatter) formatValue(data *StructuredData) string {
 	switch data.Type {
 	case TypeNull:
 		return valueNull
-	case TypeBool, TypeNumber:
+	case TypeBool:
+		return fmt.Sprint(data.Value)
+	case TypeNumber:
+		if data.Meta != nil && data.Meta.Literal != "" {
+			return data.Meta.Literal
+		}
+
 		return fmt.Sprint(data.Value)
 	case TypeString:
 		str := fmt.Sprint(data.Value)
@@ -459,15 +654,16 @@ func (f *UnifiedFormatter) formatLineDiff(w io.Writer, diff *DiffResult, indent
 			}
 		}
 	case StatusModified:
-		if diff.From != nil {
-			if _, err := fmt.Fprintf(w, "-  %s%s\n", indent, diff.From.Value); err != nil {
-				return fmt.Errorf("write modified old line: %w", err)
-			}
+		if diff.From == nil || diff.To == nil {
+			return nil
 		}
-		if diff.To != nil {
-			if _, err := fmt.Fprintf(w, "+  %s%s\n", indent, diff.To.Value); err != nil {
-				return fmt.Errorf("write modified new line: %w", err)
-			}
+
+		from, to := f.formatChangedLines(fmt.Sprint(diff.From.Value), fmt.Sprint(diff.To.Value))
+		if _, err := fmt.Fprintf(w, "-  %s%s\n", indent, from); err != nil {
+			return fmt.Errorf("write modified old line: %w", err)
+		}
+		if _, err := fmt.Fprintf(w, "+  %s%s\n", indent, to); err != nil {
+			return fmt.Errorf("write modified new line: %w", err)
 		}
 	}
 
@@ -581,14 +777,17 @@ func (f *UnifiedFormatter) hasChangedDescendants(diff *DiffResult) bool {
 }
 
 // JSONPatchFormatter implements RFC 6902 JSON Patch format.
-type JSONPatchFormatter struct{}
+// The operations apply in order to the first document and turn it into the second one.
+type JSONPatchFormatter struct {
+	Test bool // Precede remove, replace and move operations with a "test" of the current value
+}
 
 // Format formats diff results as JSON Patch.
 func (f *JSONPatchFormatter) Format(w io.Writer, results []*DiffResult) error {
 	var operations []string
 
 	for _, result := range results {
-		ops := f.generateOperations(result)
+		ops := f.generateOperations(result, jsonPointer(result.Path))
 		operations = append(operations, ops...)
 	}
 
@@ -607,49 +806,212 @@ func (f *JSONPatchFormatter) Format(w io.Writer, results []*DiffResult) error {
 	return nil
 }
 
-func (f *JSONPatchFormatter) generateOperations(diff *DiffResult) []string {
+// generateOperations returns the operations changing the value at pointer from diff.From to diff.To.
+// Moves and renames are placed by the parent; only the differences of their values are generated here.
+// Type changes are replaced like modifications: they are no differences, but the documents differ.
+func (f *JSONPatchFormatter) generateOperations(diff *DiffResult, pointer string) []string {
+	switch diff.Status {
+	case StatusSame, StatusCommentChanged:
+		if !hasTypeChanges(diff) {
+			return nil
+		}
+
+	case StatusDeleted:
+		return append(f.testOperation(pointer, diff.From), fmt.Sprintf(`{"op": "remove", "path": %s}`, jsonString(pointer)))
+
+	case StatusAdded:
+		return []string{fmt.Sprintf(`{"op": "add", "path": %s, "value": %s}`, jsonString(pointer), jsonValue(diff.To))}
+
+	case StatusModified, StatusMoved, StatusRenamed, StatusTypeChanged:
+	}
+
+	if len(diff.Children) == 0 && diff.Status != StatusModified && diff.Status != StatusTypeChanged {
+		return nil
+	}
+
+	switch {
+	case len(diff.Children) > 0 && isType(diff.From, TypeObject) && isType(diff.To, TypeObject):
+		return f.objectOperations(diff, pointer)
+	case len(diff.Children) > 0 && isType(diff.From, TypeArray) && isType(diff.To, TypeArray):
+		return f.arrayOperations(diff, pointer)
+	}
+
+	// Primitives, type changes and multiline strings are replaced as a whole
+	return append(f.testOperation(pointer, diff.From),
+		fmt.Sprintf(`{"op": "replace", "path": %s, "value": %s}`, jsonString(pointer), jsonValue(diff.To)))
+}
+
+// objectOperations returns the operations for the members of an object.
+func (f *JSONPatchFormatter) objectOperations(diff *DiffResult, pointer string) []string {
 	var ops []string
 
-	path := "/" + strings.Join(diff.Path, "/")
-	if path == "/" {
-		path = ""
+	for _, child := range diff.Children {
+		childPointer := pointer + "/" + escapeJSONPointer(lastSegment(child.Path))
+		if child.Status == StatusRenamed {
+			ops = append(ops, f.moveOperation(pointer+"/"+escapeJSONPointer(lastSegment(child.FromPath)), childPointer, child.From)...)
+		}
+		ops = append(ops, f.generateOperations(child, childPointer)...)
 	}
 
-	switch diff.Status {
-	case StatusModified:
-		if len(diff.Children) > 0 {
-			// Generate ops for children
-			for _, child := range diff.Children {
-				ops = append(ops, f.generateOperations(child)...)
-			}
-		} else {
-			// Replace operation
-			op := fmt.Sprintf(`{"op": "replace", "path": "%s", "value": %s}`,
-				path, f.jsonValue(diff.To))
-			ops = append(ops, op)
+	return ops
+}
+
+// arrayOperations returns the operations for the elements of an array.
+// Elements are tracked through the simulated array so that every index refers to
+// the array as left by the preceding operations, whichever strategy paired them.
+func (f *JSONPatchFormatter) arrayOperations(diff *DiffResult, pointer string) []string {
+	bySource := make(map[*StructuredData]*DiffResult)
+	byTarget := make(map[*StructuredData]*DiffResult)
+	for _, child := range diff.Children {
+		if child.From != nil {
+			bySource[child.From] = child
 		}
+		if child.To != nil {
+			byTarget[child.To] = child
+		}
+	}
 
-	case StatusDeleted:
-		op := fmt.Sprintf(`{"op": "remove", "path": "%s"}`, path)
-		ops = append(ops, op)
+	targets := arrayTargets(diff)
 
-	case StatusAdded:
-		op := fmt.Sprintf(`{"op": "add", "path": "%s", "value": %s}`,
-			path, f.jsonValue(diff.To))
-		ops = append(ops, op)
+	var ops []string
 
-	case StatusSame:
-		for _, child := range diff.Children {
-			if child.Status != StatusSame {
-				ops = append(ops, f.generateOperations(child)...)
-			}
+	// Remove deleted elements from the back so that the indices of the others stay valid
+	current := make([]*StructuredData, 0, len(diff.From.Elements))
+	for i := len(diff.From.Elements) - 1; i >= 0; i-- {
+		elem := diff.From.Elements[i]
+		if child, ok := bySource[elem]; ok && child.Status == StatusDeleted {
+			ops = append(ops, f.generateOperations(child, fmt.Sprintf("%s/%d", pointer, i))...)
+
+			continue
+		}
+		current = append(current, elem)
+	}
+	slices.Reverse(current)
+
+	// Place the elements of the second array one position at a time
+	for j, target := range targets {
+		elemPointer := fmt.Sprintf("%s/%d", pointer, j)
+
+		child := byTarget[target]
+		source := -1
+		if child != nil && child.From != nil {
+			source = slices.Index(current[j:], child.From)
+		}
+
+		if source < 0 {
+			ops = append(ops, fmt.Sprintf(`{"op": "add", "path": %s, "value": %s}`, jsonString(elemPointer), jsonValue(target)))
+			current = slices.Insert(current, j, target)
+
+			continue
 		}
+
+		if source += j; source != j {
+			ops = append(ops, f.moveOperation(fmt.Sprintf("%s/%d", pointer, source), elemPointer, child.From)...)
+			current = slices.Insert(slices.Delete(current, source, source+1), j, child.From)
+		}
+		ops = append(ops, f.generateOperations(child, elemPointer)...)
+	}
+
+	// Remove elements the diff did not account for
+	for i := len(current) - 1; i >= len(targets); i-- {
+		ops = append(ops, f.testOperation(fmt.Sprintf("%s/%d", pointer, i), current[i])...)
+		ops = append(ops, fmt.Sprintf(`{"op": "remove", "path": %s}`, jsonString(fmt.Sprintf("%s/%d", pointer, i))))
 	}
 
 	return ops
 }
 
-func (f *JSONPatchFormatter) jsonValue(data *StructuredData) string {
+// arrayTargets returns the elements the array holds after the patch. Elements
+// excluded from the comparison are left as they are: those only in the first
+// array stay at their index and those only in the second array are not added.
+// Excluded results hold a value found on one side only as both From and To.
+func arrayTargets(diff *DiffResult) []*StructuredData {
+	excluded := make(map[*StructuredData]bool)
+	for _, child := range diff.Children {
+		if isExcluded(child) && child.From == child.To {
+			excluded[child.From] = true
+		}
+	}
+	if len(excluded) == 0 {
+		return diff.To.Elements
+	}
+
+	inFrom := make(map[*StructuredData]bool, len(diff.From.Elements))
+	for _, elem := range diff.From.Elements {
+		inFrom[elem] = true
+	}
+	inTo := make(map[*StructuredData]bool, len(diff.To.Elements))
+	for _, elem := range diff.To.Elements {
+		inTo[elem] = true
+	}
+
+	targets := make([]*StructuredData, 0, len(diff.To.Elements))
+	for _, elem := range diff.To.Elements {
+		if !excluded[elem] || inFrom[elem] {
+			targets = append(targets, elem)
+		}
+	}
+	for i, elem := range diff.From.Elements {
+		if excluded[elem] && !inTo[elem] {
+			targets = slices.Insert(targets, min(i, len(targets)), elem)
+		}
+	}
+
+	return targets
+}
+
+// moveOperation returns a move of the value at from to path.
+func (f *JSONPatchFormatter) moveOperation(from, path string, value *StructuredData) []string {
+	return append(f.testOperation(from, value),
+		fmt.Sprintf(`{"op": "move", "from": %s, "path": %s}`, jsonString(from), jsonString(path)))
+}
+
+// testOperation returns a test of the value at pointer when Test is enabled.
+func (f *JSONPatchFormatter) testOperation(pointer string, value *StructuredData) []string {
+	if !f.Test {
+		return nil
+	}
+
+	return []string{fmt.Sprintf(`{"op": "test", "path": %s, "value": %s}`, jsonString(pointer), jsonValue(value))}
+}
+
+// isType reports whether data is non-nil and of type t.
+func isType(data *StructuredData, t DataType) bool {
+	return data != nil && data.Type == t
+}
+
+// jsonPointer converts a diff path into an RFC 6901 JSON Pointer.
+// Array index segments such as "[3]" become plain indices.
+func jsonPointer(path []string) string {
+	var b strings.Builder
+	for _, segment := range path {
+		index := strings.TrimSuffix(strings.TrimPrefix(segment, "["), "]")
+		if _, err := strconv.Atoi(index); err == nil && len(index)+2 == len(segment) {
+			segment = index
+		}
+		b.WriteString("/" + escapeJSONPointer(segment))
+	}
+
+	return b.String()
+}
+
+// escapeJSONPointer escapes a reference token as described in RFC 6901.
+func escapeJSONPointer(token string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(token)
+}
+
+// jsonString encodes s as a JSON string.
+func jsonString(s string) string {
+	var b strings.Builder
+	encoder := json.NewEncoder(&b)
+	encoder.SetEscapeHTML(false)
+	_ = encoder.Encode(s) // Encoding a string cannot fail
+
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// jsonValue encodes data as compact JSON.
+func jsonValue(data *StructuredData) string {
 	if data == nil {
 		return valueNull
 	}
@@ -660,20 +1022,20 @@ func (f *JSONPatchFormatter) jsonValue(data *StructuredData) string {
 	case TypeBool:
 		return fmt.Sprint(data.Value)
 	case TypeNumber:
-		return fmt.Sprint(data.Value)
+		return jsonNumber(data.Value)
 	case TypeString:
-		return fmt.Sprintf("%q", data.Value)
+		return jsonString(fmt.Sprint(data.Value))
 	case TypeArray:
 		var elems []string
 		for _, elem := range data.Elements {
-			elems = append(elems, f.jsonValue(elem))
+			elems = append(elems, jsonValue(elem))
 		}
 
 		return fmt.Sprintf("[%s]", strings.Join(elems, ", "))
 	case TypeObject:
 		var fields []string
-		for key, child := range data.Children {
-			fields = append(fields, fmt.Sprintf("%q: %s", key, f.jsonValue(child)))
+		for _, key := range data.ChildKeys() {
+			fields = append(fields, fmt.Sprintf("%s: %s", jsonString(key), jsonValue(data.Children[key])))
 		}
 
 		return fmt.Sprintf("{%s}", strings.Join(fields, ", "))
@@ -681,3 +1043,120 @@ func (f *JSONPatchFormatter) jsonValue(data *StructuredData) string {
 
 	return valueNull
 }
+
+// jsonNumber encodes a number as JSON. JSON has no NaN or infinities, which TOML
+// and YAML floats can hold; they are written as the strings "NaN", "Infinity" and "-Infinity".
+func jsonNumber(value any) string {
+	var f float64
+	switch v := value.(type) {
+	case float32:
+		f = float64(v)
+	case float64:
+		f = v
+	default:
+		return fmt.Sprint(value)
+	}
+
+	switch {
+	case math.IsNaN(f):
+		return `"NaN"`
+	case math.IsInf(f, 1):
+		return `"Infinity"`
+	case math.IsInf(f, -1):
+		return `"-Infinity"`
+	}
+
+	return fmt.Sprint(value)
+}
+
+// ErrMergePatchUnrepresentable is returned when a difference cannot be expressed as a JSON Merge Patch.
+var ErrMergePatchUnrepresentable = errors.New("cannot express difference as merge patch")
+
+// MergePatchFormatter implements RFC 7386 JSON Merge Patch format.
+// Deleted keys become null and arrays that differ are replaced as a whole.
+type MergePatchFormatter struct{}
+
+// Format formats diff results as one JSON Merge Patch per document.
+func (f *MergePatchFormatter) Format(w io.Writer, results []*DiffResult) error {
+	patches := make([]*StructuredData, 0, len(results))
+	for _, result := range results {
+		patch, err := f.mergePatch(result)
+		if err != nil {
+			return err
+		}
+		if patch == nil {
+			patch = &StructuredData{Type: TypeObject, Children: map[string]*StructuredData{}}
+			if result.To != nil && result.To.Type != TypeObject {
+				// A patch that is not an object replaces the target, so the unchanged
+				// target is its own patch, where {} would replace it with an object
+				patch = result.To
+			}
+		}
+		patches = append(patches, patch)
+	}
+
+	return EncodeWithFormat(w, patches, FormatJSON)
+}
+
+// mergePatch returns the patch turning diff.From into diff.To, or nil when nothing changes.
+// Type changes are replaced, as in JSON Patch.
+func (f *MergePatchFormatter) mergePatch(diff *DiffResult) (*StructuredData, error) {
+	switch diff.Status {
+	case StatusSame, StatusCommentChanged:
+		if !hasTypeChanges(diff) {
+			return nil, nil //nolint:nilnil // No patch needed
+		}
+	case StatusDeleted:
+		return &StructuredData{Type: TypeNull}, nil
+	}
+
+	if isType(diff.From, TypeArray) && isType(diff.To, TypeArray) {
+		return &StructuredData{Type: TypeArray, Elements: arrayTargets(diff), Meta: diff.To.Meta}, nil
+	}
+	if !isType(diff.From, TypeObject) || !isType(diff.To, TypeObject) {
+		return f.replacement(diff.To, diff.Path)
+	}
+
+	patch := &StructuredData{Type: TypeObject, Children: map[string]*StructuredData{}}
+	for _, child := range diff.Children {
+		if child.Status == StatusRenamed {
+			setChild(patch, lastSegment(child.FromPath), &StructuredData{Type: TypeNull})
+		}
+
+		childPatch, err := f.mergePatch(child)
+		if err != nil {
+			return nil, err
+		}
+		if child.Status == StatusRenamed {
+			// The renamed key does not exist yet, so its whole value is needed
+			childPatch, err = f.replacement(child.To, child.Path)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if childPatch != nil {
+			setChild(patch, lastSegment(child.Path), childPatch)
+		}
+	}
+
+	return patch, nil
+}
+
+// replacement returns value as a patch replacing the current value.
+// Merge patches use null to delete, so an explicit null in value cannot be expressed,
+// unless it is inside an array, which is copied as is.
+func (f *MergePatchFormatter) replacement(value *StructuredData, path []string) (*StructuredData, error) {
+	if value == nil || value.Type == TypeNull {
+		return nil, fmt.Errorf("%w: %s is null", ErrMergePatchUnrepresentable, jsonPointer(path))
+	}
+
+	if value.Type == TypeObject {
+		for _, key := range value.ChildKeys() {
+			if _, err := f.replacement(value.Children[key], append(slices.Clone(path), key)); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return value, nil
+}
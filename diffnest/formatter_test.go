@@ -1,6 +1,7 @@
 package diffnest
 
 import (
+	"errors"
 	"strings"
 	"testing"
 )
@@ -272,6 +273,173 @@ func TestUnifiedFormatter_Format(t *testing.T) {
 	}
 }
 
+func TestUnifiedFormatter_AddedObjectKeyOrder(t *testing.T) {
+	results := []*DiffResult{
+		{
+			Status: StatusAdded,
+			Path:   []string{"config"},
+			To: &StructuredData{
+				Type: TypeObject,
+				Children: map[string]*StructuredData{
+					"zeta":  {Type: TypeNumber, Value: 1},
+					"alpha": {Type: TypeNumber, Value: 2},
+					"mid":   {Type: TypeNumber, Value: 3},
+				},
+				Keys: []string{"zeta", "alpha", "mid"},
+			},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		sortKeys bool
+		want     string
+	}{
+		{
+			name: "Source order",
+			want: "+ config:\n+   zeta: 1\n+   alpha: 2\n+   mid: 3\n",
+		},
+		{
+			name:     "Sorted keys",
+			sortKeys: true,
+			want:     "+ config:\n+   alpha: 2\n+   mid: 3\n+   zeta: 1\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			formatter := &UnifiedFormatter{ShowOnlyDiff: true, ContextLines: -1, SortKeys: tt.sortKeys}
+			var buf strings.Builder
+			if err := formatter.Format(&buf, results); err != nil {
+				t.Fatalf("UnifiedFormatter.Format() error = %v", err)
+			}
+			if got := buf.String(); got != tt.want {
+				t.Errorf("UnifiedFormatter.Format() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnifiedFormatter_CommentChanges(t *testing.T) {
+	results := []*DiffResult{
+		{
+			Status: StatusModified,
+			Path:   []string{},
+			Children: []*DiffResult{
+				{
+					Status: StatusCommentChanged,
+					Path:   []string{"replicas"},
+					From:   &StructuredData{Type: TypeNumber, Value: 3, Meta: &Metadata{Comments: []string{"for prod"}}},
+					To:     &StructuredData{Type: TypeNumber, Value: 3, Meta: &Metadata{Comments: []string{"for staging"}}},
+					Meta:   &DiffMeta{DiffCount: 1, CommentChanged: true},
+				},
+				{
+					Status: StatusModified,
+					Path:   []string{"image"},
+					From:   &StructuredData{Type: TypeString, Value: "v1"},
+					To:     &StructuredData{Type: TypeString, Value: "v2", Meta: &Metadata{Comments: []string{"bumped"}}},
+					Meta:   &DiffMeta{DiffCount: 2, CommentChanged: true},
+				},
+			},
+		},
+	}
+
+	formatter := &UnifiedFormatter{ShowOnlyDiff: true, ContextLines: -1}
+	var buf strings.Builder
+	if err := formatter.Format(&buf, results); err != nil {
+		t.Fatalf("UnifiedFormatter.Format() error = %v", err)
+	}
+
+	want := "# replicas:\n" +
+		"#   - for prod\n" +
+		"#   + for staging\n" +
+		"# image:\n" +
+		"#   + bumped\n" +
+		"- image: v1\n" +
+		"+ image: v2\n"
+	if got := buf.String(); got != want {
+		t.Errorf("UnifiedFormatter.Format() = %q, want %q", got, want)
+	}
+}
+
+func TestUnifiedFormatter_TypeChanges(t *testing.T) {
+	opts := DiffOptions{CoerceTypes: true, ReportTypeChanges: true, ArrayDiffStrategy: ArrayStrategyIndex}
+	from := parseJSONDocument(t, `{"name": "web", "port": 8080, "args": [true], "image": "v1"}`)
+	to := parseJSONDocument(t, `{"name": "web", "port": "8080", "args": ["true"], "image": "v2"}`)
+	results := []*DiffResult{NewDiffEngine(opts).Compare(from, to)}
+
+	formatter := &UnifiedFormatter{ShowOnlyDiff: true, ContextLines: -1}
+	var buf strings.Builder
+	if err := formatter.Format(&buf, results); err != nil {
+		t.Fatalf("UnifiedFormatter.Format() error = %v", err)
+	}
+
+	want := "  port: 8080  (type: number -> string)\n" +
+		"  args:\n" +
+		"    - true  (type: bool -> string)\n" +
+		"- image: v1\n" +
+		"+ image: v2\n"
+	if got := buf.String(); got != want {
+		t.Errorf("UnifiedFormatter.Format() = %q, want %q", got, want)
+	}
+}
+
+func TestUnifiedFormatter_MovedAndRenamed(t *testing.T) {
+	results := []*DiffResult{
+		{
+			Status: StatusModified,
+			Path:   []string{},
+			Children: []*DiffResult{
+				{
+					Status:   StatusRenamed,
+					Path:     []string{"timeoutSeconds"},
+					FromPath: []string{"timeout"},
+					From:     &StructuredData{Type: TypeNumber, Value: 30},
+					To:       &StructuredData{Type: TypeNumber, Value: 30},
+					Meta:     &DiffMeta{DiffCount: 1},
+				},
+				{
+					Status: StatusModified,
+					Path:   []string{"items"},
+					Children: []*DiffResult{
+						{
+							Status:   StatusMoved,
+							Path:     []string{"items", "[5]"},
+							FromPath: []string{"items", "[2]"},
+							From:     &StructuredData{Type: TypeObject, Children: map[string]*StructuredData{"id": {Type: TypeNumber, Value: 1}}},
+							To:       &StructuredData{Type: TypeObject, Children: map[string]*StructuredData{"id": {Type: TypeNumber, Value: 1}}},
+							Meta:     &DiffMeta{DiffCount: 2},
+							Children: []*DiffResult{
+								{
+									Status: StatusAdded,
+									Path:   []string{"items", "[5]", "name"},
+									To:     &StructuredData{Type: TypeString, Value: "a"},
+									Meta:   &DiffMeta{DiffCount: 1},
+								},
+							},
+						},
+					},
+					Meta: &DiffMeta{DiffCount: 2},
+				},
+			},
+		},
+	}
+
+	formatter := &UnifiedFormatter{ShowOnlyDiff: true, ContextLines: -1}
+	var buf strings.Builder
+	if err := formatter.Format(&buf, results); err != nil {
+		t.Fatalf("UnifiedFormatter.Format() error = %v", err)
+	}
+
+	want := "~ renamed from timeout to timeoutSeconds: 30\n" +
+		"  items:\n" +
+		"~   moved from [2] to [5]: {1 fields}\n" +
+		"+     name: a\n"
+	if got := buf.String(); got != want {
+		t.Errorf("UnifiedFormatter.Format() = %q, want %q", got, want)
+	}
+}
+
 func TestJSONPatchFormatter_Format(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -383,7 +551,32 @@ func TestJSONPatchFormatter_Format(t *testing.T) {
 				},
 			},
 			want: []string{
-				`{"op": "replace", "path": "/items/[1]", "value": "new"}`,
+				`{"op": "replace", "path": "/items/1", "value": "new"}`,
+			},
+		},
+		{
+			name: "Move operation",
+			results: []*DiffResult{
+				{
+					Status: StatusModified,
+					Path:   []string{},
+					From:   &StructuredData{Type: TypeObject},
+					To:     &StructuredData{Type: TypeObject},
+					Children: []*DiffResult{
+						{
+							Status:   StatusRenamed,
+							Path:     []string{"timeoutSeconds"},
+							FromPath: []string{"timeout"},
+							From:     &StructuredData{Type: TypeNumber, Value: 30},
+							To:       &StructuredData{Type: TypeNumber, Value: 30},
+							Meta:     &DiffMeta{DiffCount: 1},
+						},
+					},
+					Meta: &DiffMeta{DiffCount: 1},
+				},
+			},
+			want: []string{
+				`{"op": "move", "from": "/timeout", "path": "/timeoutSeconds"}`,
 			},
 		},
 		{
@@ -438,6 +631,108 @@ func TestJSONPatchFormatter_Format(t *testing.T) {
 	}
 }
 
+func TestJSONPatchFormatter_Apply(t *testing.T) {
+	tests := []struct {
+		name     string
+		a        string
+		b        string
+		strategy ArrayDiffStrategy
+		test     bool
+		want     string
+	}{
+		{
+			name: "Pointer escaping",
+			a:    `{"a/b": 1, "m~n": 2}`,
+			b:    `{"a/b": 3, "m~n": 4}`,
+			want: `[
+  {"op": "replace", "path": "/a~1b", "value": 3},
+  {"op": "replace", "path": "/m~0n", "value": 4}
+]
+`,
+		},
+		{
+			name: "Multiline string is replaced as a whole",
+			a:    `{"script": "a\nb\nc"}`,
+			b:    `{"script": "a\nb\nd"}`,
+			want: `[
+  {"op": "replace", "path": "/script", "value": "a\nb\nd"}
+]
+`,
+		},
+		{
+			name:     "Removals are adjusted for earlier operations",
+			a:        `{"items": ["a", "b", "c", "d"]}`,
+			b:        `{"items": ["b", "d"]}`,
+			strategy: ArrayStrategyValue,
+			want: `[
+  {"op": "remove", "path": "/items/2"},
+  {"op": "remove", "path": "/items/0"}
+]
+`,
+		},
+		{
+			name:     "Insertion and modification use current indices",
+			a:        `{"items": ["a", "b"]}`,
+			b:        `{"items": ["x", "a", "B"]}`,
+			strategy: ArrayStrategyOrdered,
+			want: `[
+  {"op": "add", "path": "/items/0", "value": "x"},
+  {"op": "replace", "path": "/items/2", "value": "B"}
+]
+`,
+		},
+		{
+			name:     "Reordered elements are moved",
+			a:        `{"items": [{"id": 1}, {"id": 2}, {"id": 3}]}`,
+			b:        `{"items": [{"id": 3}, {"id": 1}, {"id": 2, "x": true}]}`,
+			strategy: ArrayStrategyValue,
+			want: `[
+  {"op": "move", "from": "/items/2", "path": "/items/0"},
+  {"op": "add", "path": "/items/2/x", "value": true}
+]
+`,
+		},
+		{
+			name: "Test operations",
+			a:    `{"name": "old", "gone": [1]}`,
+			b:    `{"name": "new"}`,
+			test: true,
+			want: `[
+  {"op": "test", "path": "/name", "value": "old"},
+  {"op": "replace", "path": "/name", "value": "new"},
+  {"op": "test", "path": "/gone", "value": [1]},
+  {"op": "remove", "path": "/gone"}
+]
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser := &JSONParser{}
+			a, err := parser.Parse(strings.NewReader(tt.a))
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			b, err := parser.Parse(strings.NewReader(tt.b))
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+
+			result := NewDiffEngine(DiffOptions{ArrayDiffStrategy: tt.strategy}).Compare(a[0], b[0])
+
+			formatter := &JSONPatchFormatter{Test: tt.test}
+			var buf strings.Builder
+			if err := formatter.Format(&buf, []*DiffResult{result}); err != nil {
+				t.Fatalf("JSONPatchFormatter.Format() error = %v", err)
+			}
+			if got := buf.String(); got != tt.want {
+				t.Errorf("JSONPatchFormatter.Format() = \n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestUnifiedFormatter_formatValue(t *testing.T) {
 	tests := []struct {
 		name string
@@ -519,6 +814,58 @@ func TestUnifiedFormatter_formatValue(t *testing.T) {
 	}
 }
 
+func TestJSONPatchFormatter_IgnoredElements(t *testing.T) {
+	tests := []struct {
+		name string
+		a    string
+		b    string
+		want string
+	}{
+		{
+			name: "Ignored element only in the first array",
+			a:    `{"items": [{"name": "a"}, {"name": "tmp"}, {"name": "b"}]}`,
+			b:    `{"items": [{"name": "a"}, {"name": "b"}]}`,
+			want: "[]\n",
+		},
+		{
+			name: "Ignored element only in the second array",
+			a:    `{"items": [{"name": "a"}, {"name": "b"}]}`,
+			b:    `{"items": [{"name": "a"}, {"name": "tmp"}, {"name": "b"}]}`,
+			want: "[]\n",
+		},
+		{
+			name: "Ignored element on both sides",
+			a:    `{"items": [{"name": "a"}, {"name": "tmp", "v": 1}]}`,
+			b:    `{"items": [{"name": "a"}, {"name": "tmp", "v": 2}]}`,
+			want: "[]\n",
+		},
+		{
+			name: "Ignored element beside an addition",
+			a:    `{"items": [{"name": "a"}, {"name": "tmp"}, {"name": "b"}]}`,
+			b:    `{"items": [{"name": "a"}, {"name": "b"}, {"name": "c"}]}`,
+			want: "[\n  {\"op\": \"add\", \"path\": \"/items/3\", \"value\": {\"name\": \"c\"}}\n]\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := DiffOptions{
+				ArrayDiffStrategy: ArrayStrategyOrdered,
+				IgnorePaths:       []PathPattern{MustParsePathPattern("items[name=tmp]")},
+			}
+			result := NewDiffEngine(opts).Compare(parseJSONDocument(t, tt.a), parseJSONDocument(t, tt.b))
+
+			var buf strings.Builder
+			if err := (&JSONPatchFormatter{}).Format(&buf, []*DiffResult{result}); err != nil {
+				t.Fatalf("JSONPatchFormatter.Format() error = %v", err)
+			}
+			if got := buf.String(); got != tt.want {
+				t.Errorf("JSONPatchFormatter.Format() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestJSONPatchFormatter_jsonValue(t *testing.T) {
 	tests := []struct {
 		name string
@@ -584,10 +931,9 @@ func TestJSONPatchFormatter_jsonValue(t *testing.T) {
 		},
 	}
 
-	formatter := &JSONPatchFormatter{}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := formatter.jsonValue(tt.data)
+			got := jsonValue(tt.data)
 			// For objects, we need to check if both possible orders are acceptable
 			if tt.data != nil && tt.data.Type == TypeObject && len(tt.data.Children) > 1 {
 				// Check if the structure is correct rather than exact string match
@@ -600,3 +946,272 @@ func TestJSONPatchFormatter_jsonValue(t *testing.T) {
 		})
 	}
 }
+
+func TestMergePatchFormatter_Format(t *testing.T) {
+	tests := []struct {
+		name    string
+		a       string
+		b       string
+		opts    DiffOptions
+		want    string
+		wantErr error
+	}{
+		{
+			name: "Nested changes and deletions",
+			a:    `{"name": "app", "spec": {"replicas": 1, "debug": true, "image": "v1"}}`,
+			b:    `{"name": "app", "spec": {"replicas": 3, "image": "v1"}, "port": 80}`,
+			want: `{"spec": {"replicas": 3, "debug": null}, "port": 80}`,
+		},
+		{
+			name: "Arrays are replaced",
+			a:    `{"tags": ["a", "b"], "other": [1]}`,
+			b:    `{"tags": ["a", "c"], "other": [1]}`,
+			opts: DiffOptions{ArrayDiffStrategy: ArrayStrategyIndex},
+			want: `{"tags": ["a", "c"]}`,
+		},
+		{
+			name: "Renamed key",
+			a:    `{"timeout": 30}`,
+			b:    `{"timeoutSeconds": 30}`,
+			opts: DiffOptions{DetectMoves: true},
+			want: `{"timeout": null, "timeoutSeconds": 30}`,
+		},
+		{
+			name: "No differences",
+			a:    `{"a": 1}`,
+			b:    `{"a": 1}`,
+			want: `{}`,
+		},
+		{
+			name: "Identical arrays",
+			a:    `[1]`,
+			b:    `[1]`,
+			want: `[1]`,
+		},
+		{
+			name: "Identical nulls",
+			a:    `null`,
+			b:    `null`,
+			want: `null`,
+		},
+		{
+			name:    "Explicit null",
+			a:       `{"a": 1}`,
+			b:       `{"a": null}`,
+			wantErr: ErrMergePatchUnrepresentable,
+		},
+		{
+			name:    "Explicit null in added object",
+			a:       `{}`,
+			b:       `{"a": {"b": null}}`,
+			wantErr: ErrMergePatchUnrepresentable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := parseJSONDocument(t, tt.a)
+			b := parseJSONDocument(t, tt.b)
+			result := NewDiffEngine(tt.opts).Compare(a, b)
+
+			var buf strings.Builder
+			err := (&MergePatchFormatter{}).Format(&buf, []*DiffResult{result})
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("MergePatchFormatter.Format() error = %v, want %v", err, tt.wantErr)
+				}
+
+				return
+			}
+			if err != nil {
+				t.Fatalf("MergePatchFormatter.Format() error = %v", err)
+			}
+
+			patch := parseJSONDocument(t, buf.String())
+			if want := parseJSONDocument(t, tt.want); jsonValue(patch) != jsonValue(want) {
+				t.Errorf("MergePatchFormatter.Format() = %s, want %s", jsonValue(patch), jsonValue(want))
+			}
+
+			// Applying the patch yields the second document
+			if got := ApplyMergePatch(a, patch); NewDiffEngine(DiffOptions{}).Compare(got, b).Status != StatusSame {
+				t.Errorf("ApplyMergePatch() = %s, want %s", jsonValue(got), jsonValue(b))
+			}
+		})
+	}
+}
+
+func TestPatchFormatters_TypeChanges(t *testing.T) {
+	opts := DiffOptions{CoerceTypes: true, ReportTypeChanges: true}
+	result := NewDiffEngine(opts).Compare(
+		parseJSONDocument(t, `{"value": 42, "n": {"port": 8080}}`),
+		parseJSONDocument(t, `{"value": "42", "n": {"port": "8080"}}`),
+	)
+
+	var jsonPatch strings.Builder
+	if err := (&JSONPatchFormatter{}).Format(&jsonPatch, []*DiffResult{result}); err != nil {
+		t.Fatalf("JSONPatchFormatter.Format() error = %v", err)
+	}
+	want := "[\n" +
+		"  {\"op\": \"replace\", \"path\": \"/value\", \"value\": \"42\"},\n" +
+		"  {\"op\": \"replace\", \"path\": \"/n/port\", \"value\": \"8080\"}\n" +
+		"]\n"
+	if got := jsonPatch.String(); got != want {
+		t.Errorf("JSONPatchFormatter.Format() = %s, want %s", got, want)
+	}
+
+	var mergePatch strings.Builder
+	if err := (&MergePatchFormatter{}).Format(&mergePatch, []*DiffResult{result}); err != nil {
+		t.Fatalf("MergePatchFormatter.Format() error = %v", err)
+	}
+	if got, want := jsonValue(parseJSONDocument(t, mergePatch.String())), `{"value": "42", "n": {"port": "8080"}}`; got != want {
+		t.Errorf("MergePatchFormatter.Format() = %s, want %s", got, want)
+	}
+}
+
+func TestUnifiedFormatter_Color(t *testing.T) {
+	results := []*DiffResult{
+		{
+			Status: StatusModified,
+			Path:   []string{},
+			Children: []*DiffResult{
+				{
+					Status: StatusSame,
+					Path:   []string{"name"},
+					From:   &StructuredData{Type: TypeString, Value: "app"},
+					To:     &StructuredData{Type: TypeString, Value: "app"},
+				},
+				{
+					Status: StatusModified,
+					Path:   []string{"spec"},
+					Children: []*DiffResult{
+						{
+							Status: StatusModified,
+							Path:   []string{"spec", "replicas"},
+							From:   &StructuredData{Type: TypeNumber, Value: 1},
+							To:     &StructuredData{Type: TypeNumber, Value: 3},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	formatter := &UnifiedFormatter{ShowOnlyDiff: true, ContextLines: 1, Color: true}
+	var buf strings.Builder
+	if err := formatter.Format(&buf, results); err != nil {
+		t.Fatalf("UnifiedFormatter.Format() error = %v", err)
+	}
+
+	want := "\x1b[2m  name: app\x1b[0m\n" +
+		"\x1b[1m  spec:\x1b[0m\n" +
+		"\x1b[31m-   replicas: 1\x1b[0m\n" +
+		"\x1b[32m+   replicas: 3\x1b[0m\n"
+	if got := buf.String(); got != want {
+		t.Errorf("UnifiedFormatter.Format() = %q, want %q", got, want)
+	}
+}
+
+func TestLineColor(t *testing.T) {
+	tests := []struct {
+		line string
+		want string
+	}{
+		{line: "- name: old", want: ansiRed},
+		{line: "+ name: new", want: ansiGreen},
+		{line: "~ moved from [0] to [1]: a", want: ansiYellow},
+		{line: "# name:", want: ansiCyan},
+		{line: "---", want: ansiBold},
+		{line: "     ...", want: ansiDim},
+		{line: "  spec:", want: ansiBold},
+		{line: "    -", want: ansiBold},
+		{line: "  name: app", want: ansiDim},
+		{line: "", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			if got := lineColor(tt.line); got != tt.want {
+				t.Errorf("lineColor(%q) = %q, want %q", tt.line, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnifiedFormatter_InlineHighlight(t *testing.T) {
+	results := []*DiffResult{
+		{
+			Status: StatusModified,
+			Path:   []string{},
+			Children: []*DiffResult{
+				{
+					Status: StatusModified,
+					Path:   []string{"image"},
+					From:   &StructuredData{Type: TypeString, Value: "app:v1"},
+					To:     &StructuredData{Type: TypeString, Value: "app:v2"},
+				},
+				{
+					Status: StatusModified,
+					Path:   []string{"name"},
+					From:   &StructuredData{Type: TypeString, Value: "web"},
+					To:     &StructuredData{Type: TypeString, Value: "api"},
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		name  string
+		color bool
+		want  string
+	}{
+		{
+			name: "Bracket markers",
+			want: "- image: \"app:[-v1-]\"\n" +
+				"+ image: \"app:{+v2+}\"\n" +
+				"- name: web\n" +
+				"+ name: api\n",
+		},
+		{
+			name:  "Inverted spans with colors",
+			color: true,
+			want: "\x1b[31m- image: \"app:\x1b[7mv1\x1b[27m\"\x1b[0m\n" +
+				"\x1b[32m+ image: \"app:\x1b[7mv2\x1b[27m\"\x1b[0m\n" +
+				"\x1b[31m- name: web\x1b[0m\n" +
+				"\x1b[32m+ name: api\x1b[0m\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			formatter := &UnifiedFormatter{ShowOnlyDiff: true, ContextLines: -1, Color: tt.color}
+			var buf strings.Builder
+			if err := formatter.Format(&buf, results); err != nil {
+				t.Fatalf("UnifiedFormatter.Format() error = %v", err)
+			}
+			if got := buf.String(); got != tt.want {
+				t.Errorf("UnifiedFormatter.Format() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnifiedFormatter_ShowDelta(t *testing.T) {
+	result := NewDiffEngine(DiffOptions{}).Compare(
+		parseJSONDocument(t, `{"ratio": 0.5, "name": "a"}`),
+		parseJSONDocument(t, `{"ratio": 0.51, "name": "b"}`),
+	)
+
+	formatter := &UnifiedFormatter{ShowOnlyDiff: true, ContextLines: -1, ShowDelta: true}
+	var buf strings.Builder
+	if err := formatter.Format(&buf, []*DiffResult{result}); err != nil {
+		t.Fatalf("UnifiedFormatter.Format() error = %v", err)
+	}
+
+	want := "- ratio: 0.5\n" +
+		"+ ratio: 0.51  (+0.01, +2%)\n" +
+		"- name: a\n" +
+		"+ name: b\n"
+	if got := buf.String(); got != want {
+		t.Errorf("UnifiedFormatter.Format() = %q, want %q", got, want)
+	}
+}
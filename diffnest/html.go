@@ -0,0 +1,246 @@
+package diffnest
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"strings"
+)
+
+// HTMLFormatter writes a self-contained HTML report showing the diff as a collapsible tree.
+// Unchanged subtrees start collapsed.
+type HTMLFormatter struct {
+	Title    string // Page title; "diffnest report" when empty
+	SortKeys bool   // Print keys of added/deleted objects alphabetically instead of by source order
+}
+
+const htmlStyle = `body{font-family:-apple-system,BlinkMacSystemFont,"Segoe UI",sans-serif;margin:2em;color:#24292f}
+h1{font-size:1.5em}
+h2{font-size:1.2em;border-bottom:1px solid #d0d7de;padding-bottom:.3em}
+.summary{display:flex;flex-wrap:wrap;gap:.5em;padding:0;list-style:none}
+.summary li{padding:.2em .6em;border-radius:1em;background:#f6f8fa;border:1px solid #d0d7de}
+.toolbar button{margin-right:.5em}
+.tree,.tree ul{list-style:none;margin:0;padding-left:1.5em;font-family:ui-monospace,SFMono-Regular,Menlo,monospace;font-size:.9em}
+.tree{padding-left:0}
+.tree li{margin:.1em 0;white-space:pre-wrap}
+.tree summary{cursor:pointer}
+.marker{display:inline-block;width:1.2em;color:#57606a}
+.key{font-weight:600}
+.hint{color:#57606a;font-style:italic}
+.added{background:#e6ffec}
+.deleted{background:#ffebe9}
+.modified>.value del,.deleted-line{background:#ffebe9}
+.modified>.value ins,.added-line{background:#e6ffec}
+.moved,.renamed{background:#fff8c5}
+.comment-changed{background:#ddf4ff}
+.type-changed{background:#f6f8fa}
+del{text-decoration:line-through}
+ins{text-decoration:none}
+.lines{margin:0;padding-left:1.5em}
+.lines span{display:block}`
+
+const htmlScript = `function setOpen(selector,open){document.querySelectorAll(selector).forEach(function(d){d.open=open})}`
+
+// Format formats diff results as an HTML page.
+func (f *HTMLFormatter) Format(w io.Writer, results []*DiffResult) error {
+	title := f.Title
+	if title == "" {
+		title = "diffnest report"
+	}
+
+	total := make(map[DiffStatus]int)
+	for _, result := range results {
+		countStatuses(result, total)
+	}
+
+	var b strings.Builder
+	b.WriteString("<!DOCTYPE html>\n<html lang=\"en\">\n<head>\n<meta charset=\"utf-8\">\n")
+	fmt.Fprintf(&b, "<title>%s</title>\n", html.EscapeString(title))
+	fmt.Fprintf(&b, "<style>\n%s\n</style>\n<script>\n%s\n</script>\n", htmlStyle, htmlScript)
+	b.WriteString("</head>\n<body>\n")
+	fmt.Fprintf(&b, "<h1>%s</h1>\n", html.EscapeString(title))
+	f.writeSummary(&b, total)
+	b.WriteString("<div class=\"toolbar\">" +
+		"<button type=\"button\" onclick=\"setOpen('details',true)\">Expand all</button>" +
+		"<button type=\"button\" onclick=\"setOpen('details.same',false)\">Collapse unchanged</button>" +
+		"</div>\n")
+
+	for i, result := range results {
+		b.WriteString("<section class=\"document\">\n")
+		if len(results) > 1 {
+			counts := make(map[DiffStatus]int)
+			countStatuses(result, counts)
+			fmt.Fprintf(&b, "<h2>Document %d</h2>\n", i+1)
+			f.writeSummary(&b, counts)
+		}
+		b.WriteString("<ul class=\"tree\">\n")
+		f.writeDiff(&b, result)
+		b.WriteString("</ul>\n</section>\n")
+	}
+
+	b.WriteString("</body>\n</html>\n")
+
+	if _, err := io.WriteString(w, b.String()); err != nil {
+		return fmt.Errorf("write html report: %w", err)
+	}
+
+	return nil
+}
+
+func (f *HTMLFormatter) writeSummary(b *strings.Builder, counts map[DiffStatus]int) {
+	b.WriteString("<ul class=\"summary\">")
+	for _, status := range summaryStatuses {
+		fmt.Fprintf(b, "<li class=\"%s\">%s: %d</li>", status, statusLabel(status), counts[status])
+	}
+	b.WriteString("</ul>\n")
+}
+
+// writeDiff writes diff as a tree item. The root of a document has no key and
+// writes its children directly.
+func (f *HTMLFormatter) writeDiff(b *strings.Builder, diff *DiffResult) {
+	values := &UnifiedFormatter{}
+	key := f.key(diff.Path)
+
+	switch diff.Status {
+	case StatusAdded:
+		f.writeData(b, diff.To, key, StatusAdded, "+")
+
+		return
+	case StatusDeleted:
+		f.writeData(b, diff.From, key, StatusDeleted, "-")
+
+		return
+	case StatusMoved, StatusRenamed:
+		verb := "moved"
+		if diff.Status == StatusRenamed {
+			verb = "renamed"
+		}
+		fmt.Fprintf(b, "<li class=\"%s\"><details open><summary><span class=\"marker\">~</span>%s"+
+			"<span class=\"hint\">%s from %s</span> <span class=\"value\">%s</span></summary>\n<ul>\n",
+			diff.Status, key, verb, html.EscapeString(lastSegment(diff.FromPath)), html.EscapeString(values.formatValue(diff.To)))
+		for _, child := range diff.Children {
+			f.writeDiff(b, child)
+		}
+		b.WriteString("</ul></details></li>\n")
+
+		return
+	case StatusSame, StatusModified, StatusCommentChanged, StatusTypeChanged:
+	}
+
+	if len(diff.Children) == 0 {
+		if diff.Status == StatusModified {
+			fmt.Fprintf(b, "<li class=\"modified\"><span class=\"marker\">~</span>%s<span class=\"value\"><del>%s</del> → <ins>%s</ins></span></li>\n",
+				key, html.EscapeString(values.formatValue(diff.From)), html.EscapeString(values.formatValue(diff.To)))
+
+			return
+		}
+
+		if diff.Status == StatusTypeChanged {
+			fmt.Fprintf(b, "<li class=\"type-changed\"><span class=\"marker\">=</span>%s<span class=\"value\">%s</span> <span class=\"hint\">%s</span></li>\n",
+				key, html.EscapeString(values.formatValue(diff.To)), html.EscapeString(typeChange(diff)))
+
+			return
+		}
+
+		marker := ""
+		if diff.Status == StatusCommentChanged {
+			marker = "#"
+		}
+		f.writeData(b, diff.From, key, diff.Status, marker)
+
+		return
+	}
+
+	if isType(diff.From, TypeString) && isType(diff.To, TypeString) {
+		f.writeLines(b, diff, key)
+
+		return
+	}
+
+	if len(diff.Path) == 0 {
+		for _, child := range diff.Children {
+			f.writeDiff(b, child)
+		}
+
+		return
+	}
+
+	open := " open"
+	if diff.Status == StatusSame && !hasTypeChanges(diff) {
+		open = ""
+	}
+	fmt.Fprintf(b, "<li class=\"%s\"><details class=\"%s\"%s><summary><span class=\"marker\"></span>%s<span class=\"hint\">%s</span></summary>\n<ul>\n",
+		diff.Status, diff.Status, open, key, html.EscapeString(values.formatValue(diff.To)))
+	for _, child := range diff.Children {
+		f.writeDiff(b, child)
+	}
+	b.WriteString("</ul></details></li>\n")
+}
+
+// writeLines writes the line diff of a multiline string.
+func (f *HTMLFormatter) writeLines(b *strings.Builder, diff *DiffResult, key string) {
+	fmt.Fprintf(b, "<li class=\"%s\"><details class=\"%s\" open><summary><span class=\"marker\">~</span>%s<span class=\"hint\">multiline string</span></summary>\n<pre class=\"lines\">",
+		diff.Status, diff.Status, key)
+
+	for _, line := range diff.Children {
+		switch line.Status {
+		case StatusSame:
+			fmt.Fprintf(b, "<span>  %s</span>", html.EscapeString(fmt.Sprint(line.From.Value)))
+		case StatusDeleted:
+			fmt.Fprintf(b, "<span class=\"deleted-line\">- %s</span>", html.EscapeString(fmt.Sprint(line.From.Value)))
+		case StatusAdded:
+			fmt.Fprintf(b, "<span class=\"added-line\">+ %s</span>", html.EscapeString(fmt.Sprint(line.To.Value)))
+		default:
+			fmt.Fprintf(b, "<span class=\"deleted-line\">- %s</span><span class=\"added-line\">+ %s</span>",
+				html.EscapeString(fmt.Sprint(line.From.Value)), html.EscapeString(fmt.Sprint(line.To.Value)))
+		}
+	}
+
+	b.WriteString("</pre></details></li>\n")
+}
+
+// writeData writes a whole value with one status. Non-empty objects and arrays become
+// collapsible items, open unless they are unchanged.
+func (f *HTMLFormatter) writeData(b *strings.Builder, data *StructuredData, key string, status DiffStatus, marker string) {
+	values := &UnifiedFormatter{SortKeys: f.SortKeys}
+	if data == nil {
+		return
+	}
+
+	isContainer := (data.Type == TypeObject && len(data.Children) > 0) ||
+		(data.Type == TypeArray && len(data.Elements) > 0)
+	if !isContainer {
+		fmt.Fprintf(b, "<li class=\"%s\"><span class=\"marker\">%s</span>%s<span class=\"value\">%s</span></li>\n",
+			status, marker, key, html.EscapeString(values.formatValue(data)))
+
+		return
+	}
+
+	open := " open"
+	if status == StatusSame {
+		open = ""
+	}
+	fmt.Fprintf(b, "<li class=\"%s\"><details class=\"%s\"%s><summary><span class=\"marker\">%s</span>%s<span class=\"hint\">%s</span></summary>\n<ul>\n",
+		status, status, open, marker, key, html.EscapeString(values.formatValue(data)))
+
+	if data.Type == TypeObject {
+		for _, childKey := range values.objectKeys(data) {
+			f.writeData(b, data.Children[childKey], f.key([]string{childKey}), status, marker)
+		}
+	} else {
+		for i, elem := range data.Elements {
+			f.writeData(b, elem, f.key([]string{fmt.Sprintf("[%d]", i)}), status, marker)
+		}
+	}
+
+	b.WriteString("</ul></details></li>\n")
+}
+
+// key returns the escaped key label for the last segment of path.
+func (f *HTMLFormatter) key(path []string) string {
+	if len(path) == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf("<span class=\"key\">%s:</span> ", html.EscapeString(lastSegment(path)))
+}
@@ -0,0 +1,56 @@
+package diffnest
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHTMLFormatter_Format(t *testing.T) {
+	engine := NewDiffEngine(DiffOptions{})
+	results := []*DiffResult{
+		engine.Compare(
+			parseJSONDocument(t, `{"name": "<app>", "spec": {"replicas": 1, "ports": [80]}, "meta": {"team": "a"}}`),
+			parseJSONDocument(t, `{"name": "<app>", "spec": {"replicas": 3, "ports": [80]}, "meta": {"team": "a"}, "tags": ["web"]}`),
+		),
+		engine.Compare(
+			parseJSONDocument(t, `{"old": true}`),
+			parseJSONDocument(t, `{}`),
+		),
+	}
+
+	formatter := &HTMLFormatter{Title: "Release <1.2>"}
+	var buf strings.Builder
+	if err := formatter.Format(&buf, results); err != nil {
+		t.Fatalf("HTMLFormatter.Format() error = %v", err)
+	}
+	got := buf.String()
+
+	wants := []string{
+		"<title>Release &lt;1.2&gt;</title>",
+		// Summary of all documents
+		`<li class="modified">Modified: 1</li><li class="added">Added: 1</li><li class="deleted">Deleted: 1</li>`,
+		`<li class="same">Unchanged: 3</li>`,
+		// Per-document sections
+		"<h2>Document 1</h2>",
+		"<h2>Document 2</h2>",
+		`<span class="key">name:</span> <span class="value">&lt;app&gt;</span>`,
+		`<span class="key">replicas:</span> <span class="value"><del>1</del> → <ins>3</ins></span>`,
+		// Unchanged subtrees start collapsed, changed ones open
+		`<details class="same"><summary><span class="marker"></span><span class="key">meta:</span>`,
+		`<details class="modified" open><summary><span class="marker"></span><span class="key">spec:</span>`,
+		`<li class="added"><details class="added" open><summary><span class="marker">+</span><span class="key">tags:</span>`,
+		`<li class="deleted"><span class="marker">-</span><span class="key">old:</span> <span class="value">true</span></li>`,
+	}
+	for _, want := range wants {
+		if !strings.Contains(got, want) {
+			t.Errorf("HTMLFormatter.Format() missing %q in:\n%s", want, got)
+		}
+	}
+
+	// The report must not load external assets
+	for _, external := range []string{"src=", "href=", "@import", "url("} {
+		if strings.Contains(got, external) {
+			t.Errorf("HTMLFormatter.Format() references external asset with %q", external)
+		}
+	}
+}
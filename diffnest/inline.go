@@ -0,0 +1,148 @@
+package diffnest
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Markers around changed spans of modified strings when colors are disabled.
+const (
+	deletedSpanStart = "[-"
+	deletedSpanEnd   = "-]"
+	addedSpanStart   = "{+"
+	addedSpanEnd     = "+}"
+)
+
+// inlineMaxWords is the number of words above which strings are not compared word by word.
+const inlineMaxWords = 1000
+
+// inlineSpan is a run of text that is either shared by both strings or only in one of them.
+type inlineSpan struct {
+	text    string
+	changed bool
+}
+
+// inlineDiff compares a and b word by word and returns their spans.
+// ok is false when the strings share no word, as marking everything would not help,
+// or when either has more than inlineMaxWords words.
+func inlineDiff(a, b string) (from, to []inlineSpan, ok bool) {
+	aWords := splitWords(a)
+	bWords := splitWords(b)
+	if len(aWords) > inlineMaxWords || len(bWords) > inlineMaxWords {
+		return nil, nil, false
+	}
+
+	matches, found := myersMatches(len(aWords), len(bWords), func(i, j int) bool {
+		return aWords[i] == bWords[j]
+	})
+	if !found {
+		return nil, nil, false
+	}
+
+	for _, match := range matches {
+		if strings.TrimSpace(aWords[match[0]]) != "" {
+			ok = true
+
+			break
+		}
+	}
+	if !ok {
+		return nil, nil, false
+	}
+
+	i, j := 0, 0
+	for _, match := range append(matches, [2]int{len(aWords), len(bWords)}) {
+		from = appendSpan(from, strings.Join(aWords[i:match[0]], ""), true)
+		to = appendSpan(to, strings.Join(bWords[j:match[1]], ""), true)
+		if match[0] < len(aWords) {
+			from = appendSpan(from, aWords[match[0]], false)
+			to = appendSpan(to, bWords[match[1]], false)
+		}
+		i, j = match[0]+1, match[1]+1
+	}
+
+	return from, to, true
+}
+
+// appendSpan appends text to spans, merging it into the last span when both are changed or both are not.
+func appendSpan(spans []inlineSpan, text string, changed bool) []inlineSpan {
+	if text == "" {
+		return spans
+	}
+
+	if len(spans) > 0 && spans[len(spans)-1].changed == changed {
+		spans[len(spans)-1].text += text
+
+		return spans
+	}
+
+	return append(spans, inlineSpan{text: text, changed: changed})
+}
+
+// splitWords splits s into runs of letters and digits, runs of spaces, and single other characters.
+func splitWords(s string) []string {
+	var words []string
+
+	for s != "" {
+		r, size := utf8.DecodeRuneInString(s)
+		end := size
+
+		if class := runeClass(r); class != 0 {
+			for end < len(s) {
+				next, nextSize := utf8.DecodeRuneInString(s[end:])
+				if runeClass(next) != class {
+					break
+				}
+				end += nextSize
+			}
+		}
+
+		words = append(words, s[:end])
+		s = s[end:]
+	}
+
+	return words
+}
+
+// runeClass groups runes that form a word together. Punctuation has class 0 and stands alone.
+func runeClass(r rune) int {
+	switch {
+	case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_':
+		return 1
+	case unicode.IsSpace(r):
+		return 2
+	default:
+		return 0
+	}
+}
+
+// renderSpans joins spans, wrapping changed ones in start and end.
+// With quote, the text is escaped as in a Go string literal and surrounded by quotes.
+func renderSpans(spans []inlineSpan, start, end string, quote bool) string {
+	var b strings.Builder
+	if quote {
+		b.WriteByte('"')
+	}
+
+	for _, span := range spans {
+		text := span.text
+		if quote {
+			quoted := strconv.Quote(text)
+			text = quoted[1 : len(quoted)-1]
+		}
+
+		if span.changed {
+			b.WriteString(start + text + end)
+		} else {
+			b.WriteString(text)
+		}
+	}
+
+	if quote {
+		b.WriteByte('"')
+	}
+
+	return b.String()
+}
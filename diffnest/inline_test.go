@@ -0,0 +1,80 @@
+package diffnest
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSplitWords(t *testing.T) {
+	got := splitWords("registry.io/app:v1.2  -Xmx512m")
+	want := []string{"registry", ".", "io", "/", "app", ":", "v1", ".", "2", "  ", "-", "Xmx512m"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("splitWords() = %q, want %q", got, want)
+	}
+}
+
+func TestInlineDiff(t *testing.T) {
+	tests := []struct {
+		name     string
+		a        string
+		b        string
+		wantOK   bool
+		wantFrom string
+		wantTo   string
+	}{
+		{
+			name:     "Changed image tag",
+			a:        "registry.io/team/app:v1.2.3",
+			b:        "registry.io/team/app:v1.2.4",
+			wantOK:   true,
+			wantFrom: "registry.io/team/app:v1.2.[-3-]",
+			wantTo:   "registry.io/team/app:v1.2.{+4+}",
+		},
+		{
+			name:     "Inserted option",
+			a:        "-Xms256m -Xmx512m",
+			b:        "-Xms256m -XX:+UseG1GC -Xmx512m",
+			wantOK:   true,
+			wantFrom: "-Xms256m -Xmx512m",
+			wantTo:   "-Xms256m -{+XX:+UseG1GC -+}Xmx512m",
+		},
+		{
+			name:   "Nothing in common",
+			a:      "alpha",
+			b:      "beta",
+			wantOK: false,
+		},
+		{
+			name:   "Only spaces in common",
+			a:      "a b",
+			b:      "c d",
+			wantOK: false,
+		},
+		{
+			name:   "Too many words",
+			a:      strings.Repeat("old ", 3000) + "end",
+			b:      strings.Repeat("new ", 3000) + "end",
+			wantOK: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			from, to, ok := inlineDiff(tt.a, tt.b)
+			if ok != tt.wantOK {
+				t.Fatalf("inlineDiff() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if !ok {
+				return
+			}
+
+			if got := renderSpans(from, deletedSpanStart, deletedSpanEnd, false); got != tt.wantFrom {
+				t.Errorf("from = %q, want %q", got, tt.wantFrom)
+			}
+			if got := renderSpans(to, addedSpanStart, addedSpanEnd, false); got != tt.wantTo {
+				t.Errorf("to = %q, want %q", got, tt.wantTo)
+			}
+		})
+	}
+}
@@ -0,0 +1,334 @@
+package diffnest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+// JSONResultVersion is the version of the schema written by JSONFormatter.
+// It changes only when existing fields change meaning or are removed.
+// Results of earlier versions can still be read.
+const JSONResultVersion = 2
+
+var (
+	ErrUnsupportedResultVersion = errors.New("unsupported diff result version")
+	ErrInvalidResult            = errors.New("invalid diff result")
+)
+
+// jsonResults is the top-level object of the JSON result schema.
+type jsonResults struct {
+	Version int           `json:"version"`
+	Results []*jsonResult `json:"results"`
+}
+
+// jsonResult is a DiffResult in the JSON result schema. From and To hold the
+// compared values as JSON and are left out when a side does not exist.
+// Since version 2, a side is also left out when it can be rebuilt from the values of the
+// children, given the Type of the value, so the output does not repeat every subtree at
+// each of its ancestors. Values with members or elements the children do not hold in
+// order, such as reordered arrays, are still written.
+type jsonResult struct {
+	Status   string          `json:"status"`
+	Path     []string        `json:"path"`
+	FromPath []string        `json:"fromPath,omitempty"`
+	Type     string          `json:"type,omitempty"`
+	From     json.RawMessage `json:"from,omitempty"`
+	To       json.RawMessage `json:"to,omitempty"`
+	Meta     *jsonResultMeta `json:"meta,omitempty"`
+	Children []*jsonResult   `json:"children,omitempty"`
+}
+
+type jsonResultMeta struct {
+	DiffCount      int               `json:"diffCount"`
+	Note           string            `json:"note,omitempty"`
+	CommentChanged bool              `json:"commentChanged,omitempty"`
+	Excluded       bool              `json:"excluded,omitempty"`
+	Document       *jsonDocumentPair `json:"document,omitempty"`
+}
+
+type jsonDocumentPair struct {
+	From int `json:"from"`
+	To   int `json:"to"`
+}
+
+// JSONFormatter writes the complete DiffResult tree as JSON, to be read back with DecodeJSONResults.
+type JSONFormatter struct{}
+
+// Format formats diff results as JSON.
+func (f *JSONFormatter) Format(w io.Writer, results []*DiffResult) error {
+	out := jsonResults{Version: JSONResultVersion, Results: make([]*jsonResult, 0, len(results))}
+	for _, result := range results {
+		out.Results = append(out.Results, toJSONResult(result))
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(out); err != nil {
+		return fmt.Errorf("encode diff results: %w", err)
+	}
+
+	return nil
+}
+
+func toJSONResult(diff *DiffResult) *jsonResult {
+	result := &jsonResult{
+		Status:   diff.Status.String(),
+		Path:     append([]string{}, diff.Path...),
+		FromPath: diff.FromPath,
+	}
+
+	// Values the children hold in order are left out and rebuilt when decoding
+	fromRebuilt := diff.From != nil && hasSide(diff.Children, fromSide) &&
+		sameMembers(rebuildValue(diff.From.Type, diff.Children, fromSide), diff.From)
+	toRebuilt := diff.To != nil && hasSide(diff.Children, toSide) &&
+		sameMembers(rebuildValue(diff.To.Type, diff.Children, toSide), diff.To) &&
+		(!fromRebuilt || diff.To.Type == diff.From.Type)
+
+	switch {
+	case fromRebuilt:
+		result.Type = diff.From.Type.String()
+	case toRebuilt:
+		result.Type = diff.To.Type.String()
+	}
+	if diff.From != nil && !fromRebuilt {
+		result.From = json.RawMessage(jsonValue(diff.From))
+	}
+	if diff.To != nil && !toRebuilt {
+		result.To = json.RawMessage(jsonValue(diff.To))
+	}
+
+	if diff.Meta != nil {
+		result.Meta = &jsonResultMeta{
+			DiffCount:      diff.Meta.DiffCount,
+			Note:           diff.Meta.Note,
+			CommentChanged: diff.Meta.CommentChanged,
+			Excluded:       diff.Meta.Excluded,
+		}
+		if diff.Meta.Document != nil {
+			result.Meta.Document = &jsonDocumentPair{From: diff.Meta.Document.From, To: diff.Meta.Document.To}
+		}
+	}
+
+	for _, child := range diff.Children {
+		result.Children = append(result.Children, toJSONResult(child))
+	}
+
+	return result
+}
+
+// fromSide returns the value of diff in the first file and its path there.
+func fromSide(diff *DiffResult) (*StructuredData, []string) {
+	if diff.FromPath != nil {
+		return diff.From, diff.FromPath
+	}
+
+	return diff.From, diff.Path
+}
+
+// toSide returns the value of diff in the second file and its path there.
+func toSide(diff *DiffResult) (*StructuredData, []string) {
+	return diff.To, diff.Path
+}
+
+// hasSide reports whether any of children has a value on the side returned by side.
+func hasSide(children []*DiffResult, side func(*DiffResult) (*StructuredData, []string)) bool {
+	return slices.ContainsFunc(children, func(child *DiffResult) bool {
+		value, _ := side(child)
+
+		return value != nil
+	})
+}
+
+// sameMembers reports whether rebuilt holds the same members, elements or lines as data, in the same order.
+func sameMembers(rebuilt, data *StructuredData) bool {
+	switch data.Type {
+	case TypeObject:
+		if !slices.Equal(rebuilt.Keys, data.ChildKeys()) {
+			return false
+		}
+		for key, child := range rebuilt.Children {
+			if data.Children[key] != child {
+				return false
+			}
+		}
+
+		return true
+	case TypeArray:
+		return slices.Equal(rebuilt.Elements, data.Elements)
+	case TypeString:
+		return rebuilt.Value == data.Value
+	case TypeNull, TypeBool, TypeNumber:
+	}
+
+	return false
+}
+
+// DecodeJSONResults reads diff results written by JSONFormatter.
+// Values are decoded as by JSONParser, so format-specific metadata is not restored.
+// Values left out on nodes with children are rebuilt from the children: objects
+// hold the compared members and arrays the compared elements, in the order of the children.
+func DecodeJSONResults(r io.Reader) ([]*DiffResult, error) {
+	var in jsonResults
+	if err := json.NewDecoder(r).Decode(&in); err != nil {
+		return nil, fmt.Errorf("decode diff results: %w", err)
+	}
+
+	if in.Version < 1 || in.Version > JSONResultVersion {
+		return nil, fmt.Errorf("%w: %d", ErrUnsupportedResultVersion, in.Version)
+	}
+
+	results := make([]*DiffResult, 0, len(in.Results))
+	for i, result := range in.Results {
+		diff, err := fromJSONResult(result)
+		if err != nil {
+			return nil, fmt.Errorf("result [%d]: %w", i, err)
+		}
+		results = append(results, diff)
+	}
+
+	return results, nil
+}
+
+func fromJSONResult(result *jsonResult) (*DiffResult, error) {
+	if result == nil {
+		return nil, fmt.Errorf("%w: null result", ErrInvalidResult)
+	}
+
+	status, err := ParseDiffStatus(result.Status)
+	if err != nil {
+		return nil, err
+	}
+
+	diff := &DiffResult{
+		Status:   status,
+		Path:     result.Path,
+		FromPath: result.FromPath,
+	}
+	if diff.Path == nil {
+		diff.Path = []string{}
+	}
+
+	if diff.From, err = decodeJSONValue(result.From); err != nil {
+		return nil, fmt.Errorf("from value at %s: %w", displayPath(diff.Path), err)
+	}
+	if diff.To, err = decodeJSONValue(result.To); err != nil {
+		return nil, fmt.Errorf("to value at %s: %w", displayPath(diff.Path), err)
+	}
+
+	if result.Meta != nil {
+		diff.Meta = &DiffMeta{
+			DiffCount:      result.Meta.DiffCount,
+			Note:           result.Meta.Note,
+			CommentChanged: result.Meta.CommentChanged,
+			Excluded:       result.Meta.Excluded,
+		}
+		if result.Meta.Document != nil {
+			diff.Meta.Document = &DocumentPair{From: result.Meta.Document.From, To: result.Meta.Document.To}
+		}
+	}
+
+	for _, child := range result.Children {
+		childDiff, err := fromJSONResult(child)
+		if err != nil {
+			return nil, err
+		}
+		diff.Children = append(diff.Children, childDiff)
+	}
+
+	if result.Type != "" {
+		dataType, err := parseContainerType(result.Type)
+		if err != nil {
+			return nil, fmt.Errorf("type at %s: %w", displayPath(diff.Path), err)
+		}
+		if diff.From == nil && hasSide(diff.Children, fromSide) {
+			diff.From = rebuildValue(dataType, diff.Children, fromSide)
+		}
+		if diff.To == nil && hasSide(diff.Children, toSide) {
+			diff.To = rebuildValue(dataType, diff.Children, toSide)
+		}
+	}
+
+	return diff, nil
+}
+
+// parseContainerType returns the type of values rebuilt from their children.
+func parseContainerType(name string) (DataType, error) {
+	for _, dataType := range []DataType{TypeObject, TypeArray, TypeString} {
+		if dataType.String() == name {
+			return dataType, nil
+		}
+	}
+
+	return TypeNull, fmt.Errorf("%w: unknown type %q", ErrInvalidResult, name)
+}
+
+// rebuildValue builds a value of the given type from the values of children on one side,
+// which side returns with their paths. Strings are rebuilt from their lines.
+func rebuildValue(dataType DataType, children []*DiffResult, side func(*DiffResult) (*StructuredData, []string)) *StructuredData {
+	data := &StructuredData{Type: dataType, Meta: &Metadata{Format: FormatJSON}}
+	var lines []string
+
+	for _, child := range children {
+		value, path := side(child)
+		if value == nil {
+			continue
+		}
+
+		switch dataType {
+		case TypeObject:
+			if data.Children == nil {
+				data.Children = make(map[string]*StructuredData)
+			}
+			key := lastSegment(path)
+			if _, ok := data.Children[key]; !ok {
+				data.Keys = append(data.Keys, key)
+			}
+			data.Children[key] = value
+		case TypeArray:
+			data.Elements = append(data.Elements, value)
+		case TypeString:
+			lines = append(lines, fmt.Sprint(value.Value))
+		case TypeNull, TypeBool, TypeNumber:
+		}
+	}
+
+	if dataType == TypeString {
+		data.Value = strings.Join(lines, "\n")
+	}
+
+	return data
+}
+
+// decodeJSONValue parses a value of the JSON result schema. Missing values decode to nil.
+func decodeJSONValue(raw json.RawMessage) (*StructuredData, error) {
+	if len(raw) == 0 {
+		return nil, nil //nolint:nilnil // A missing side is not an error
+	}
+
+	docs, err := (&JSONParser{}).Parse(bytes.NewReader(raw))
+	if err != nil {
+		return nil, err
+	}
+	if len(docs) != 1 {
+		return nil, fmt.Errorf("%w: expected a single value", ErrInvalidResult)
+	}
+
+	return docs[0], nil
+}
+
+// ParseDiffStatus returns the status with the given name, as returned by DiffStatus.String.
+func ParseDiffStatus(name string) (DiffStatus, error) {
+	for _, status := range summaryStatuses {
+		if status.String() == name {
+			return status, nil
+		}
+	}
+
+	return StatusSame, fmt.Errorf("%w: unknown status %q", ErrInvalidResult, name)
+}
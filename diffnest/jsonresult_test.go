@@ -0,0 +1,145 @@
+package diffnest
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestJSONFormatter_RoundTrip(t *testing.T) {
+	docsA, err := ParseWithFormat(strings.NewReader("name: app\nscript: |-\n  a\n  b\nitems: [1, 2]\n---\nkind: Old\n"), FormatYAML)
+	if err != nil {
+		t.Fatalf("ParseWithFormat() error = %v", err)
+	}
+	docsB, err := ParseWithFormat(strings.NewReader("name: web\nscript: |-\n  a\n  c\nitems: [2, 1]\nport: null\n"), FormatYAML)
+	if err != nil {
+		t.Fatalf("ParseWithFormat() error = %v", err)
+	}
+	results := Compare(docsA, docsB, DiffOptions{ArrayDiffStrategy: ArrayStrategyOrdered, DetectMoves: true})
+
+	var encoded strings.Builder
+	if err := (&JSONFormatter{}).Format(&encoded, results); err != nil {
+		t.Fatalf("JSONFormatter.Format() error = %v", err)
+	}
+
+	decoded, err := DecodeJSONResults(strings.NewReader(encoded.String()))
+	if err != nil {
+		t.Fatalf("DecodeJSONResults() error = %v", err)
+	}
+
+	var reencoded strings.Builder
+	if err := (&JSONFormatter{}).Format(&reencoded, decoded); err != nil {
+		t.Fatalf("JSONFormatter.Format() error = %v", err)
+	}
+	if reencoded.String() != encoded.String() {
+		t.Errorf("round trip changed output:\n%s\nwant:\n%s", reencoded.String(), encoded.String())
+	}
+
+	if len(decoded) != 2 {
+		t.Fatalf("DecodeJSONResults() returned %d results, want 2", len(decoded))
+	}
+	// Values left out of the output are rebuilt from the children
+	if got, want := jsonValue(decoded[0].From), jsonValue(docsA[0]); got != want {
+		t.Errorf("decoded from = %s, want %s", got, want)
+	}
+	if got, want := jsonValue(decoded[0].To), jsonValue(docsB[0]); got != want {
+		t.Errorf("decoded to = %s, want %s", got, want)
+	}
+	if pair := decoded[1].Meta.Document; pair == nil || pair.From != 1 || pair.To != -1 {
+		t.Errorf("second result document = %+v, want {From:1 To:-1}", pair)
+	}
+
+	var port *DiffResult
+	for _, child := range decoded[0].Children {
+		if lastSegment(child.Path) == "port" {
+			port = child
+		}
+	}
+	if port == nil || port.Status != StatusAdded || port.From != nil || port.To == nil || port.To.Type != TypeNull {
+		t.Errorf("added null value decoded as %+v", port)
+	}
+}
+
+func TestJSONFormatter_NonFiniteNumbers(t *testing.T) {
+	docsA, err := ParseWithFormat(strings.NewReader("a = 1.0\nb = nan\nc = 2.0\n"), FormatTOML)
+	if err != nil {
+		t.Fatalf("ParseWithFormat() error = %v", err)
+	}
+	docsB, err := ParseWithFormat(strings.NewReader("a = inf\nb = nan\nc = -inf\n"), FormatTOML)
+	if err != nil {
+		t.Fatalf("ParseWithFormat() error = %v", err)
+	}
+
+	var encoded strings.Builder
+	if err := (&JSONFormatter{}).Format(&encoded, Compare(docsA, docsB, DiffOptions{})); err != nil {
+		t.Fatalf("JSONFormatter.Format() error = %v", err)
+	}
+
+	for _, want := range []string{`"to": "Infinity"`, `"from": "NaN"`, `"to": "-Infinity"`} {
+		if !strings.Contains(encoded.String(), want) {
+			t.Errorf("JSONFormatter.Format() = %s, want %s", encoded.String(), want)
+		}
+	}
+	if _, err := DecodeJSONResults(strings.NewReader(encoded.String())); err != nil {
+		t.Errorf("DecodeJSONResults() error = %v", err)
+	}
+}
+
+func TestJSONFormatter_SizeProportionalToInput(t *testing.T) {
+	// A long value nested deeply is written once per side, not once per ancestor
+	long := strings.Repeat("x", 100000)
+	from, to := `"`+long+`a"`, `"`+long+`b"`
+	for i := range 20 {
+		from = fmt.Sprintf(`{"level%d": %s, "n": %d}`, i, from, i)
+		to = fmt.Sprintf(`{"level%d": %s, "n": %d}`, i, to, i)
+	}
+
+	result := NewDiffEngine(DiffOptions{}).Compare(parseJSONDocument(t, from), parseJSONDocument(t, to))
+
+	var encoded strings.Builder
+	if err := (&JSONFormatter{}).Format(&encoded, []*DiffResult{result}); err != nil {
+		t.Fatalf("JSONFormatter.Format() error = %v", err)
+	}
+
+	if size, limit := encoded.Len(), 2*(len(from)+len(to)); size > limit {
+		t.Errorf("JSONFormatter.Format() wrote %d bytes, want at most %d", size, limit)
+	}
+}
+
+func TestDecodeJSONResults_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr error
+	}{
+		{
+			name:    "Unsupported version",
+			input:   `{"version": 3, "results": []}`,
+			wantErr: ErrUnsupportedResultVersion,
+		},
+		{
+			name:    "Unknown status",
+			input:   `{"version": 1, "results": [{"status": "changed", "path": []}]}`,
+			wantErr: ErrInvalidResult,
+		},
+		{
+			name:    "Unknown type",
+			input:   `{"version": 2, "results": [{"status": "modified", "path": [], "type": "set", "children": []}]}`,
+			wantErr: ErrInvalidResult,
+		},
+		{
+			name:    "Null child",
+			input:   `{"version": 1, "results": [{"status": "modified", "path": [], "children": [null]}]}`,
+			wantErr: ErrInvalidResult,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := DecodeJSONResults(strings.NewReader(tt.input)); !errors.Is(err, tt.wantErr) {
+				t.Errorf("DecodeJSONResults() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
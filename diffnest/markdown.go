@@ -0,0 +1,210 @@
+package diffnest
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// defaultMarkdownMaxBytes keeps comments under GitHub's limit of 65536 characters.
+	defaultMarkdownMaxBytes = 65000
+	// markdownCellLength is the maximum length of old and new values in the summary table.
+	markdownCellLength = 80
+	// markdownNoteReserve is the room kept for the note about truncated content.
+	markdownNoteReserve = 200
+)
+
+// MarkdownFormatter writes a summary table of changes followed by a collapsible
+// diff block per document, for pull request comments. Output that would exceed
+// MaxBytes is truncated with a note.
+type MarkdownFormatter struct {
+	ShowOnlyDiff bool
+	ContextLines int
+	SortKeys     bool // Print keys of added/deleted objects alphabetically instead of by source order
+	MaxBytes     int  // Size budget; defaultMarkdownMaxBytes when not positive
+}
+
+// Format formats diff results as Markdown.
+func (f *MarkdownFormatter) Format(w io.Writer, results []*DiffResult) error {
+	maxBytes := f.MaxBytes
+	if maxBytes <= 0 {
+		maxBytes = defaultMarkdownMaxBytes
+	}
+	budget := maxBytes - markdownNoteReserve
+
+	var rows []string
+	counts := make(map[DiffStatus]int)
+	for i, result := range results {
+		walkValues(result, func(value *DiffResult) {
+			if value.Status != StatusSame {
+				rows = append(rows, f.tableRow(i+1, value))
+				counts[value.Status]++
+			}
+		})
+	}
+
+	var b strings.Builder
+	if len(rows) == 0 {
+		b.WriteString("No differences found.\n")
+
+		return f.write(w, b.String())
+	}
+
+	// Type-only changes are listed but, as in the stat summary, not counted as changes
+	heading := pluralize(changeCount(counts), "change")
+	if typeChanges := counts[StatusTypeChanged]; typeChanges > 0 {
+		heading += ", " + pluralize(typeChanges, "type change")
+	}
+	fmt.Fprintf(&b, "### %s\n\n", heading)
+	b.WriteString("| Document | Path | Change | Old | New |\n| --- | --- | --- | --- | --- |\n")
+
+	omittedRows := 0
+	for i, row := range rows {
+		if b.Len()+len(row) > budget {
+			omittedRows = len(rows) - i
+
+			break
+		}
+		b.WriteString(row)
+	}
+	if omittedRows > 0 {
+		fmt.Fprintf(&b, "\n_%s omitted from the table to stay under the size limit._\n", pluralize(omittedRows, "more change"))
+	}
+
+	omittedSections := 0
+	for i, result := range results {
+		if !hasChanges(result) {
+			continue
+		}
+		if omittedSections > 0 {
+			omittedSections++
+
+			continue
+		}
+
+		section, err := f.section(i+1, result, budget-b.Len())
+		if err != nil {
+			return err
+		}
+		if section == "" {
+			omittedSections++
+
+			continue
+		}
+		b.WriteString(section)
+	}
+	if omittedSections > 0 {
+		fmt.Fprintf(&b, "\n_Diffs of %s omitted to stay under the size limit._\n", pluralize(omittedSections, "more document"))
+	}
+
+	return f.write(w, b.String())
+}
+
+func (f *MarkdownFormatter) write(w io.Writer, content string) error {
+	if _, err := io.WriteString(w, content); err != nil {
+		return fmt.Errorf("write markdown: %w", err)
+	}
+
+	return nil
+}
+
+// tableRow returns the summary table row of a changed value.
+func (f *MarkdownFormatter) tableRow(document int, value *DiffResult) string {
+	values := &UnifiedFormatter{}
+	change := value.Status.String()
+	if value.Status == StatusMoved || value.Status == StatusRenamed {
+		change += " from " + markdownCode(displayPath(value.FromPath))
+	}
+	if value.Status == StatusTypeChanged {
+		change = typeChange(value)
+	}
+
+	oldValue, newValue := "", ""
+	if value.Status != StatusAdded {
+		oldValue = markdownCode(truncateRunes(values.formatValue(value.From), markdownCellLength))
+	}
+	if value.Status != StatusDeleted {
+		newValue = markdownCode(truncateRunes(values.formatValue(value.To), markdownCellLength))
+	}
+
+	return fmt.Sprintf("| %d | %s | %s | %s | %s |\n", document, markdownCode(displayPath(value.Path)), change, oldValue, newValue)
+}
+
+// section returns the collapsible diff block of a document, cut to fit in budget bytes.
+// It returns an empty string when not even a truncated block fits or there is nothing to show.
+func (f *MarkdownFormatter) section(document int, result *DiffResult, budget int) (string, error) {
+	counts := make(map[DiffStatus]int)
+	countStatuses(result, counts)
+	changes := changeCount(counts)
+	if changes == 0 {
+		return "", nil
+	}
+
+	var diff strings.Builder
+	unified := &UnifiedFormatter{ShowOnlyDiff: f.ShowOnlyDiff, ContextLines: f.ContextLines, SortKeys: f.SortKeys}
+	if err := unified.Format(&diff, []*DiffResult{result}); err != nil {
+		return "", err
+	}
+
+	fence := "```"
+	for strings.Contains(diff.String(), fence) {
+		fence += "`"
+	}
+
+	header := fmt.Sprintf("\n<details>\n<summary>Document %d: %s</summary>\n\n%sdiff\n", document, pluralize(changes, "change"), fence)
+	footer := fence + "\n\n</details>\n"
+	const truncated = "... (truncated)\n"
+
+	lines := strings.SplitAfter(diff.String(), "\n")
+	size := len(header) + len(footer)
+	if size+len(truncated) > budget {
+		return "", nil
+	}
+
+	var body strings.Builder
+	for i, line := range lines {
+		remaining := len(truncated)
+		if i == len(lines)-1 {
+			remaining = 0
+		}
+		if size+len(line)+remaining > budget {
+			body.WriteString(truncated)
+
+			break
+		}
+		size += len(line)
+		body.WriteString(line)
+	}
+
+	return header + body.String() + footer, nil
+}
+
+// markdownCode returns s as inline code that is safe in table cells.
+func markdownCode(s string) string {
+	s = strings.NewReplacer("|", `\|`, "\n", `\n`).Replace(s)
+	if strings.Contains(s, "`") {
+		return "`` " + s + " ``"
+	}
+
+	return "`" + s + "`"
+}
+
+// truncateRunes cuts s to at most n runes, ending with "…" when cut.
+func truncateRunes(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+
+	return string([]rune(s)[:n-1]) + "…"
+}
+
+// pluralize returns count followed by noun, adding "s" unless count is 1.
+func pluralize(count int, noun string) string {
+	if count == 1 {
+		return fmt.Sprintf("%d %s", count, noun)
+	}
+
+	return fmt.Sprintf("%d %ss", count, noun)
+}
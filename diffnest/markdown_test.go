@@ -0,0 +1,143 @@
+package diffnest
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestMarkdownFormatter_Format(t *testing.T) {
+	result := NewDiffEngine(DiffOptions{}).Compare(
+		parseJSONDocument(t, `{"name": "app", "cmd": "a|b", "spec": {"replicas": 1}, "old": true}`),
+		parseJSONDocument(t, `{"name": "app", "cmd": "a|c", "spec": {"replicas": 3}, "tags": ["web"]}`),
+	)
+
+	formatter := &MarkdownFormatter{ShowOnlyDiff: true, ContextLines: -1}
+	var buf strings.Builder
+	if err := formatter.Format(&buf, []*DiffResult{result}); err != nil {
+		t.Fatalf("MarkdownFormatter.Format() error = %v", err)
+	}
+
+	want := "### 4 changes\n\n" +
+		"| Document | Path | Change | Old | New |\n" +
+		"| --- | --- | --- | --- | --- |\n" +
+		"| 1 | `cmd` | modified | `a\\|b` | `a\\|c` |\n" +
+		"| 1 | `spec.replicas` | modified | `1` | `3` |\n" +
+		"| 1 | `tags` | added |  | `[1 items]` |\n" +
+		"| 1 | `old` | deleted | `true` |  |\n" +
+		"\n<details>\n<summary>Document 1: 4 changes</summary>\n\n" +
+		"```diff\n" +
+		"- cmd: a|[-b-]\n" +
+		"+ cmd: a|{+c+}\n" +
+		"  spec:\n" +
+		"-   replicas: 1\n" +
+		"+   replicas: 3\n" +
+		"+ tags:\n" +
+		"+   - web\n" +
+		"- old: true\n" +
+		"```\n\n</details>\n"
+	if got := buf.String(); got != want {
+		t.Errorf("MarkdownFormatter.Format() =\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestMarkdownFormatter_TypeChanges(t *testing.T) {
+	result := NewDiffEngine(DiffOptions{CoerceTypes: true, ReportTypeChanges: true}).Compare(
+		parseJSONDocument(t, `{"n": {"port": 8080}, "image": "v1"}`),
+		parseJSONDocument(t, `{"n": {"port": "8080"}, "image": "v2"}`),
+	)
+
+	formatter := &MarkdownFormatter{ShowOnlyDiff: true, ContextLines: -1}
+	var buf strings.Builder
+	if err := formatter.Format(&buf, []*DiffResult{result}); err != nil {
+		t.Fatalf("MarkdownFormatter.Format() error = %v", err)
+	}
+
+	want := "### 1 change, 1 type change\n\n" +
+		"| Document | Path | Change | Old | New |\n" +
+		"| --- | --- | --- | --- | --- |\n" +
+		"| 1 | `n.port` | type: number -> string | `8080` | `8080` |\n" +
+		"| 1 | `image` | modified | `v1` | `v2` |\n"
+	if got := buf.String(); !strings.HasPrefix(got, want) {
+		t.Errorf("MarkdownFormatter.Format() =\n%s\nwant prefix:\n%s", got, want)
+	}
+}
+
+func TestMarkdownFormatter_SizeBudget(t *testing.T) {
+	var from, to []string
+	for i := range 200 {
+		from = append(from, fmt.Sprintf(`"key%03d": 1`, i))
+		to = append(to, fmt.Sprintf(`"key%03d": 2`, i))
+	}
+
+	engine := NewDiffEngine(DiffOptions{})
+	results := []*DiffResult{
+		engine.Compare(
+			parseJSONDocument(t, "{"+strings.Join(from, ",")+"}"),
+			parseJSONDocument(t, "{"+strings.Join(to, ",")+"}"),
+		),
+		engine.Compare(parseJSONDocument(t, `{"a": 1}`), parseJSONDocument(t, `{"a": 2}`)),
+	}
+
+	tests := []struct {
+		name     string
+		maxBytes int
+		wants    []string
+	}{
+		{
+			name:     "Diff block truncated",
+			maxBytes: 12000,
+			wants: []string{
+				"| 2 | `a` | modified | `1` | `2` |",
+				"... (truncated)\n```\n\n</details>",
+				"_Diffs of 1 more document omitted to stay under the size limit._",
+			},
+		},
+		{
+			name:     "Table truncated",
+			maxBytes: 1500,
+			wants: []string{
+				"more changes omitted from the table to stay under the size limit._",
+				"_Diffs of 2 more documents omitted to stay under the size limit._",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			formatter := &MarkdownFormatter{ShowOnlyDiff: true, ContextLines: -1, MaxBytes: tt.maxBytes}
+			var buf strings.Builder
+			if err := formatter.Format(&buf, results); err != nil {
+				t.Fatalf("MarkdownFormatter.Format() error = %v", err)
+			}
+
+			got := buf.String()
+			if len(got) > tt.maxBytes {
+				t.Errorf("MarkdownFormatter.Format() wrote %d bytes, budget %d", len(got), tt.maxBytes)
+			}
+			for _, want := range tt.wants {
+				if !strings.Contains(got, want) {
+					t.Errorf("MarkdownFormatter.Format() missing %q in:\n%s", want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestMarkdownCode(t *testing.T) {
+	tests := []struct {
+		s    string
+		want string
+	}{
+		{s: "plain", want: "`plain`"},
+		{s: "a|b", want: "`a\\|b`"},
+		{s: "x`y", want: "`` x`y ``"},
+		{s: "a\nb", want: "`a\\nb`"},
+	}
+
+	for _, tt := range tests {
+		if got := markdownCode(tt.s); got != tt.want {
+			t.Errorf("markdownCode(%q) = %q, want %q", tt.s, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,276 @@
+package diffnest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// ErrUnknownNormalizer is returned for normalizer names without a built-in normalizer.
+var ErrUnknownNormalizer = errors.New("unknown normalizer")
+
+// Normalizer canonicalizes values before they are compared, so that different
+// spellings of the same value, like 1Gi and 1024Mi, compare equal. Normalize
+// returns false for values it does not apply to. Results show the original values.
+type Normalizer interface {
+	Normalize(data *StructuredData) (*StructuredData, bool)
+}
+
+// PathNormalizer is a normalizer for values at paths matching Path.
+type PathNormalizer struct {
+	Path       PathPattern
+	Normalizer Normalizer
+}
+
+// Names of the built-in normalizers.
+const (
+	NormalizerQuantity  = "quantity"
+	NormalizerDuration  = "duration"
+	NormalizerTimestamp = "timestamp"
+	NormalizerBool      = "bool"
+	NormalizerNumber    = "number"
+)
+
+// NormalizerByName returns the built-in normalizer with the given name.
+func NormalizerByName(name string) (Normalizer, error) {
+	switch name {
+	case NormalizerQuantity:
+		return QuantityNormalizer{}, nil
+	case NormalizerDuration:
+		return DurationNormalizer{}, nil
+	case NormalizerTimestamp:
+		return TimestampNormalizer{}, nil
+	case NormalizerBool:
+		return BoolNormalizer{}, nil
+	case NormalizerNumber:
+		return NumberNormalizer{}, nil
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownNormalizer, name)
+	}
+}
+
+// QuantityNormalizer reads Kubernetes resource quantities such as 500m, 1Gi or
+// 1e3, and numbers, as exact numbers, so 1Gi equals 1024Mi and 500m equals 0.5.
+type QuantityNormalizer struct{}
+
+// quantityPattern splits a quantity into its number and suffix.
+var quantityPattern = regexp.MustCompile(`^([+-]?(?:[0-9]+(?:\.[0-9]*)?|\.[0-9]+))(?:([eE][+-]?[0-9]+)|([a-zA-Z]*))$`)
+
+// quantitySuffixes are the multipliers of the binary and decimal SI suffixes.
+//
+//nolint:gochecknoglobals
+var quantitySuffixes = map[string]*big.Rat{
+	"":   big.NewRat(1, 1),
+	"n":  big.NewRat(1, 1_000_000_000),
+	"u":  big.NewRat(1, 1_000_000),
+	"m":  big.NewRat(1, 1_000),
+	"k":  big.NewRat(1_000, 1),
+	"M":  big.NewRat(1_000_000, 1),
+	"G":  big.NewRat(1_000_000_000, 1),
+	"T":  big.NewRat(1_000_000_000_000, 1),
+	"P":  big.NewRat(1_000_000_000_000_000, 1),
+	"E":  big.NewRat(1_000_000_000_000_000_000, 1),
+	"Ki": big.NewRat(1<<10, 1),
+	"Mi": big.NewRat(1<<20, 1),
+	"Gi": big.NewRat(1<<30, 1),
+	"Ti": big.NewRat(1<<40, 1),
+	"Pi": big.NewRat(1<<50, 1),
+	"Ei": big.NewRat(1<<60, 1),
+}
+
+// Normalize implements Normalizer.
+func (QuantityNormalizer) Normalize(data *StructuredData) (*StructuredData, bool) {
+	if data.Type == TypeNumber {
+		return data, true
+	}
+	if data.Type != TypeString {
+		return nil, false
+	}
+
+	match := quantityPattern.FindStringSubmatch(fmt.Sprint(data.Value))
+	if match == nil {
+		return nil, false
+	}
+
+	value, ok := new(big.Rat).SetString(match[1] + match[2])
+	multiplier, known := quantitySuffixes[match[3]]
+	if !ok || !known {
+		return nil, false
+	}
+
+	return normalizedNumber(data, value.Mul(value, multiplier)), true
+}
+
+// DurationNormalizer reads Go durations such as 90s or 1m30s as a number of
+// nanoseconds, so 60s equals 1m.
+type DurationNormalizer struct{}
+
+// Normalize implements Normalizer.
+func (DurationNormalizer) Normalize(data *StructuredData) (*StructuredData, bool) {
+	if data.Type != TypeString {
+		return nil, false
+	}
+
+	duration, err := time.ParseDuration(fmt.Sprint(data.Value))
+	if err != nil {
+		return nil, false
+	}
+
+	return normalizedNumber(data, big.NewRat(int64(duration), 1)), true
+}
+
+// TimestampNormalizer reads RFC 3339 timestamps as instants, so timestamps in
+// different time zones compare equal when they name the same time.
+type TimestampNormalizer struct{}
+
+// timestampLayouts are RFC 3339 with a "T" or, as YAML allows, a space between date and time.
+//
+//nolint:gochecknoglobals
+var timestampLayouts = []string{time.RFC3339Nano, "2006-01-02 15:04:05.999999999Z07:00"}
+
+// Normalize implements Normalizer.
+func (TimestampNormalizer) Normalize(data *StructuredData) (*StructuredData, bool) {
+	if data.Type != TypeString {
+		return nil, false
+	}
+
+	for _, layout := range timestampLayouts {
+		if t, err := time.Parse(layout, fmt.Sprint(data.Value)); err == nil {
+			return &StructuredData{Type: TypeString, Value: t.UTC().Format(time.RFC3339Nano), Meta: data.Meta}, true
+		}
+	}
+
+	return nil, false
+}
+
+// BoolNormalizer reads the YAML 1.1 booleans true/false, yes/no, on/off and y/n,
+// in any case, whether written as booleans or strings.
+type BoolNormalizer struct{}
+
+// Normalize implements Normalizer.
+func (BoolNormalizer) Normalize(data *StructuredData) (*StructuredData, bool) {
+	if data.Type == TypeBool {
+		return data, true
+	}
+	if data.Type != TypeString {
+		return nil, false
+	}
+
+	var value bool
+	switch strings.ToLower(fmt.Sprint(data.Value)) {
+	case "true", "yes", "on", "y":
+		value = true
+	case "false", "no", "off", "n":
+		value = false
+	default:
+		return nil, false
+	}
+
+	return &StructuredData{Type: TypeBool, Value: value, Meta: data.Meta}, true
+}
+
+// NumberNormalizer reads strings holding JSON numbers, such as "8080", as numbers.
+type NumberNormalizer struct{}
+
+// Normalize implements Normalizer.
+func (NumberNormalizer) Normalize(data *StructuredData) (*StructuredData, bool) {
+	if data.Type == TypeNumber {
+		return data, true
+	}
+	if data.Type != TypeString {
+		return nil, false
+	}
+
+	literal := strings.TrimSpace(fmt.Sprint(data.Value))
+	if !isJSONNumber(literal) {
+		return nil, false
+	}
+
+	return &StructuredData{Type: TypeNumber, Value: json.Number(literal), Meta: data.Meta}, true
+}
+
+func normalizedNumber(data *StructuredData, value *big.Rat) *StructuredData {
+	return &StructuredData{Type: TypeNumber, Value: value, Meta: data.Meta}
+}
+
+// normalize returns a and b canonicalized by the first normalizer applying to
+// both, trying those of matching PathNormalizers in order before the global ones.
+// a and b are returned as is when none applies.
+func (e *DiffEngine) normalize(path []string, a, b *StructuredData) (*StructuredData, *StructuredData) {
+	if len(e.options.Normalizers) == 0 && len(e.options.PathNormalizers) == 0 {
+		return a, b
+	}
+	if isContainer(a) || isContainer(b) {
+		return a, b
+	}
+
+	var normalizers []Normalizer
+	if len(e.options.PathNormalizers) > 0 {
+		nodes := e.pathNodes(path, a, b)
+		for _, rule := range e.options.PathNormalizers {
+			if rule.Path.match(path, nodes)&(matchExact|matchInside) != 0 {
+				normalizers = append(normalizers, rule.Normalizer)
+			}
+		}
+	}
+	normalizers = append(normalizers, e.options.Normalizers...)
+
+	for _, normalizer := range normalizers {
+		normalizedA, okA := normalizer.Normalize(a)
+		normalizedB, okB := normalizer.Normalize(b)
+		if okA && okB {
+			return normalizedA, normalizedB
+		}
+	}
+
+	return a, b
+}
+
+// coerceTypes converts a string on one side holding a literal of the type of the
+// other side, such as "8080" opposite a number, to that type.
+func coerceTypes(a, b *StructuredData) (*StructuredData, *StructuredData, bool) {
+	switch {
+	case a.Type == TypeString && b.Type != TypeString:
+		if coerced, ok := coerceLiteral(a, b.Type); ok {
+			return coerced, b, true
+		}
+	case b.Type == TypeString && a.Type != TypeString:
+		if coerced, ok := coerceLiteral(b, a.Type); ok {
+			return a, coerced, true
+		}
+	}
+
+	return a, b, false
+}
+
+// coerceLiteral reads the string data as a literal of type to, using the spellings
+// of the YAML core schema for booleans and null.
+func coerceLiteral(data *StructuredData, to DataType) (*StructuredData, bool) {
+	literal := fmt.Sprint(data.Value)
+
+	switch to {
+	case TypeNumber:
+		if isJSONNumber(literal) {
+			return &StructuredData{Type: TypeNumber, Value: json.Number(literal), Meta: data.Meta}, true
+		}
+	case TypeBool:
+		switch literal {
+		case "true", "True", "TRUE":
+			return &StructuredData{Type: TypeBool, Value: true, Meta: data.Meta}, true
+		case "false", "False", "FALSE":
+			return &StructuredData{Type: TypeBool, Value: false, Meta: data.Meta}, true
+		}
+	case TypeNull:
+		switch literal {
+		case "null", "Null", "NULL", "~":
+			return &StructuredData{Type: TypeNull, Meta: data.Meta}, true
+		}
+	case TypeString, TypeArray, TypeObject:
+	}
+
+	return nil, false
+}
@@ -0,0 +1,228 @@
+package diffnest
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestNormalizers(t *testing.T) {
+	tests := []struct {
+		name       string
+		normalizer string
+		a          any
+		b          any
+		wantEqual  bool
+	}{
+		{name: "Binary quantities", normalizer: NormalizerQuantity, a: "1Gi", b: "1024Mi", wantEqual: true},
+		{name: "Millicores", normalizer: NormalizerQuantity, a: "500m", b: 0.5, wantEqual: true},
+		{name: "Decimal quantities", normalizer: NormalizerQuantity, a: "1.5k", b: "1500", wantEqual: true},
+		{name: "Exponent", normalizer: NormalizerQuantity, a: "1e3", b: "1k", wantEqual: true},
+		{name: "Exa is no exponent", normalizer: NormalizerQuantity, a: "2E", b: "2000P", wantEqual: true},
+		{name: "Decimal is not binary", normalizer: NormalizerQuantity, a: "1G", b: "1Gi", wantEqual: false},
+		{name: "Durations", normalizer: NormalizerDuration, a: "60s", b: "1m", wantEqual: true},
+		{name: "Compound duration", normalizer: NormalizerDuration, a: "1m30s", b: "90000ms", wantEqual: true},
+		{name: "Different durations", normalizer: NormalizerDuration, a: "1m", b: "1h", wantEqual: false},
+		{name: "Time zones", normalizer: NormalizerTimestamp, a: "2024-01-02T03:04:05Z", b: "2024-01-02T12:04:05+09:00", wantEqual: true},
+		{name: "Fractional seconds", normalizer: NormalizerTimestamp, a: "2024-01-02T03:04:05.500Z", b: "2024-01-02 03:04:05.5Z", wantEqual: true},
+		{name: "Different instants", normalizer: NormalizerTimestamp, a: "2024-01-02T03:04:05Z", b: "2024-01-02T03:04:05+09:00", wantEqual: false},
+		{name: "YAML booleans", normalizer: NormalizerBool, a: "yes", b: true, wantEqual: true},
+		{name: "Boolean case", normalizer: NormalizerBool, a: "Off", b: "FALSE", wantEqual: true},
+		{name: "Different booleans", normalizer: NormalizerBool, a: "on", b: "no", wantEqual: false},
+		{name: "Quoted number", normalizer: NormalizerNumber, a: "8080", b: 8080, wantEqual: true},
+		{name: "Quoted decimal", normalizer: NormalizerNumber, a: "1.50", b: 1.5, wantEqual: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			normalizer, err := NormalizerByName(tt.normalizer)
+			if err != nil {
+				t.Fatalf("NormalizerByName() error = %v", err)
+			}
+
+			opts := DiffOptions{Normalizers: []Normalizer{normalizer}}
+			from := &StructuredData{Type: TypeObject, Children: map[string]*StructuredData{"v": scalarData(tt.a)}, Keys: []string{"v"}}
+			to := &StructuredData{Type: TypeObject, Children: map[string]*StructuredData{"v": scalarData(tt.b)}, Keys: []string{"v"}}
+			result := NewDiffEngine(opts).Compare(from, to)
+			if got := result.Status == StatusSame; got != tt.wantEqual {
+				t.Errorf("Compare() status = %v, want equal %v", result.Status, tt.wantEqual)
+			}
+		})
+	}
+}
+
+func TestNormalizers_NotApplicable(t *testing.T) {
+	tests := []struct {
+		normalizer string
+		value      string
+	}{
+		{normalizer: NormalizerQuantity, value: "1Xi"},
+		{normalizer: NormalizerQuantity, value: "Gi"},
+		{normalizer: NormalizerDuration, value: "soon"},
+		{normalizer: NormalizerTimestamp, value: "2024-01-02"},
+		{normalizer: NormalizerBool, value: "maybe"},
+		{normalizer: NormalizerNumber, value: "0x1F"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.normalizer+" "+tt.value, func(t *testing.T) {
+			normalizer, err := NormalizerByName(tt.normalizer)
+			if err != nil {
+				t.Fatalf("NormalizerByName() error = %v", err)
+			}
+			if _, ok := normalizer.Normalize(&StructuredData{Type: TypeString, Value: tt.value}); ok {
+				t.Errorf("Normalize(%q) applied, want not applicable", tt.value)
+			}
+		})
+	}
+
+	if _, err := NormalizerByName("semver"); !errors.Is(err, ErrUnknownNormalizer) {
+		t.Errorf("NormalizerByName() error = %v, want ErrUnknownNormalizer", err)
+	}
+}
+
+func TestDiffEngine_PathNormalizers(t *testing.T) {
+	from := parseYAMLDocument(t, "resources:\n  memory: 1Gi\n  cpu: 500m\ntimeout: 1m\nname: 1m\n")
+	to := parseYAMLDocument(t, "resources:\n  memory: 1024Mi\n  cpu: 0.5\ntimeout: 60s\nname: 60s\n")
+
+	opts := DiffOptions{
+		PathNormalizers: []PathNormalizer{
+			{Path: MustParsePathPattern("resources"), Normalizer: QuantityNormalizer{}},
+			{Path: MustParsePathPattern("timeout"), Normalizer: DurationNormalizer{}},
+		},
+	}
+	result := NewDiffEngine(opts).Compare(from, to)
+
+	statuses := map[string]DiffStatus{}
+	var timeout *DiffResult
+	for _, child := range result.Children {
+		key := child.Path[len(child.Path)-1]
+		statuses[key] = child.Status
+		if key == "timeout" {
+			timeout = child
+		}
+	}
+	for key, want := range map[string]DiffStatus{"resources": StatusSame, "timeout": StatusSame, "name": StatusModified} {
+		if statuses[key] != want {
+			t.Errorf("Compare() %s = %v, want %v", key, statuses[key], want)
+		}
+	}
+
+	// Results keep the values as written
+	if timeout.From.Value != "1m" || timeout.To.Value != "60s" {
+		t.Errorf("Compare() timeout = %v -> %v, want 1m -> 60s", timeout.From.Value, timeout.To.Value)
+	}
+}
+
+func scalarData(v any) *StructuredData {
+	switch v.(type) {
+	case string:
+		return &StructuredData{Type: TypeString, Value: v}
+	case bool:
+		return &StructuredData{Type: TypeBool, Value: v}
+	default:
+		return &StructuredData{Type: TypeNumber, Value: v}
+	}
+}
+
+func parseYAMLDocument(t *testing.T, content string) *StructuredData {
+	t.Helper()
+
+	docs, err := (&YAMLParser{}).Parse(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	return docs[0]
+}
+
+func TestDiffEngine_CoerceTypes(t *testing.T) {
+	tests := []struct {
+		name       string
+		from       string
+		to         string
+		opts       DiffOptions
+		wantStatus DiffStatus
+		wantDiff   bool
+	}{
+		{name: "Without coercion", from: `{"port": 8080}`, to: `{"port": "8080"}`, wantStatus: StatusModified, wantDiff: true},
+		{name: "Number", from: `{"port": 8080}`, to: `{"port": "8080"}`, opts: DiffOptions{CoerceTypes: true}, wantStatus: StatusSame},
+		{name: "Decimal", from: `{"ratio": 0.50}`, to: `{"ratio": "0.5"}`, opts: DiffOptions{CoerceTypes: true}, wantStatus: StatusSame},
+		{name: "Boolean", from: `{"debug": "True"}`, to: `{"debug": true}`, opts: DiffOptions{CoerceTypes: true}, wantStatus: StatusSame},
+		{name: "Null", from: `{"tag": null}`, to: `{"tag": "~"}`, opts: DiffOptions{CoerceTypes: true}, wantStatus: StatusSame},
+		{name: "Different number", from: `{"port": 8080}`, to: `{"port": "8081"}`, opts: DiffOptions{CoerceTypes: true}, wantStatus: StatusModified, wantDiff: true},
+		{name: "Not a literal", from: `{"debug": false}`, to: `{"debug": "no"}`, opts: DiffOptions{CoerceTypes: true}, wantStatus: StatusModified, wantDiff: true},
+		{name: "Both strings", from: `{"port": "8080"}`, to: `{"port": "8080.0"}`, opts: DiffOptions{CoerceTypes: true}, wantStatus: StatusModified, wantDiff: true},
+		{
+			name:       "Reported type change",
+			from:       `{"port": 8080}`,
+			to:         `{"port": "8080"}`,
+			opts:       DiffOptions{CoerceTypes: true, ReportTypeChanges: true},
+			wantStatus: StatusTypeChanged,
+		},
+		{
+			name:       "Reported type change beside a modification",
+			from:       `{"port": 8080, "image": "a"}`,
+			to:         `{"port": "8080", "image": "b"}`,
+			opts:       DiffOptions{CoerceTypes: true, ReportTypeChanges: true},
+			wantStatus: StatusTypeChanged,
+			wantDiff:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := NewDiffEngine(tt.opts).Compare(parseJSONDocument(t, tt.from), parseJSONDocument(t, tt.to))
+
+			var got *DiffResult
+			for _, child := range result.Children {
+				if key := child.Path[0]; key == "port" || key == "ratio" || key == "debug" || key == "tag" {
+					got = child
+				}
+			}
+			if got == nil || got.Status != tt.wantStatus {
+				t.Fatalf("Compare() status = %v, want %v", got, tt.wantStatus)
+			}
+			if diffCount(got) != 0 && tt.wantStatus != StatusModified {
+				t.Errorf("Compare() diff count = %d, want 0", diffCount(got))
+			}
+			if got.From.Type == got.To.Type && tt.name != "Both strings" {
+				t.Errorf("Compare() = %v -> %v, want the values as written", got.From, got.To)
+			}
+			if HasDifferences([]*DiffResult{result}) != tt.wantDiff {
+				t.Errorf("HasDifferences() = %v, want %v", !tt.wantDiff, tt.wantDiff)
+			}
+		})
+	}
+}
+
+func TestDiffEngine_CoerceTypesInArrays(t *testing.T) {
+	opts := DiffOptions{CoerceTypes: true, ReportTypeChanges: true, ArrayDiffStrategy: ArrayStrategyOrdered}
+	result := NewDiffEngine(opts).Compare(parseJSONDocument(t, `[80, 443, 8080]`), parseJSONDocument(t, `["80", 443, "8080"]`))
+
+	counts := make(map[DiffStatus]int)
+	countStatuses(result, counts)
+	if counts[StatusTypeChanged] != 2 || counts[StatusSame] != 1 || changeCount(counts) != 0 {
+		t.Errorf("Compare() statuses = %v, want 2 type changes and 1 unchanged", counts)
+	}
+}
+
+func TestDiffEngine_TypeChangesLeaveParentsUnchanged(t *testing.T) {
+	opts := DiffOptions{CoerceTypes: true, ReportTypeChanges: true}
+	result := NewDiffEngine(opts).Compare(parseJSONDocument(t, `{"n": {"port": 8080}}`), parseJSONDocument(t, `{"n": {"port": "8080"}}`))
+
+	if result.Status != StatusSame || diffCount(result) != 0 {
+		t.Errorf("Compare() = %v with diff count %d, want same with 0", result.Status, diffCount(result))
+	}
+	if n := result.Children[0]; n.Status != StatusSame || n.Children[0].Status != StatusTypeChanged {
+		t.Errorf("Compare() n = %v with port %v, want same with a type change", n.Status, n.Children[0].Status)
+	}
+
+	var buf strings.Builder
+	if err := (&JSONFormatter{}).Format(&buf, []*DiffResult{result}); err != nil {
+		t.Fatalf("JSONFormatter.Format() error = %v", err)
+	}
+	if strings.Contains(buf.String(), `"status": "modified"`) {
+		t.Errorf("JSONFormatter.Format() = %s, want no modified values", buf.String())
+	}
+}
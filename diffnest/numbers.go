@@ -0,0 +1,66 @@
+package diffnest
+
+import (
+	"encoding/json"
+	"math"
+	"math/big"
+	"strconv"
+)
+
+// Numbers are parsed as json.Number where the source literal allows, keeping the
+// exact digits of 64-bit IDs and decimals. Values decoded as Go numbers, such as
+// TOML floats and YAML hexadecimal integers, are compared alongside them through
+// exact rationals.
+
+// numberRat returns v as an exact rational. Floats are taken by their shortest
+// decimal representation, so the float 0.1 equals the literal 0.1.
+func numberRat(v any) (*big.Rat, bool) {
+	switch val := v.(type) {
+	case json.Number:
+		return new(big.Rat).SetString(string(val))
+	case *big.Rat:
+		return val, true
+	case uint:
+		return new(big.Rat).SetUint64(uint64(val)), true
+	case uint64:
+		return new(big.Rat).SetUint64(val), true
+	case float32:
+		return floatRat(float64(val), 32)
+	case float64:
+		return floatRat(val, 64)
+	}
+
+	if i, ok := toInt64(v); ok {
+		return new(big.Rat).SetInt64(i), true
+	}
+
+	return nil, false
+}
+
+func floatRat(f float64, bitSize int) (*big.Rat, bool) {
+	if math.IsInf(f, 0) || math.IsNaN(f) {
+		return nil, false
+	}
+
+	return new(big.Rat).SetString(strconv.FormatFloat(f, 'g', -1, bitSize))
+}
+
+// isJSONNumber reports whether literal is a number in JSON syntax, which is how
+// numbers are kept as json.Number.
+func isJSONNumber(literal string) bool {
+	if literal == "" || (literal[0] != '-' && (literal[0] < '0' || literal[0] > '9')) {
+		return false
+	}
+
+	var number json.Number
+
+	return json.Unmarshal([]byte(literal), &number) == nil
+}
+
+// yamlNumber is a number written to YAML as its literal.
+type yamlNumber json.Number
+
+// MarshalYAML implements yaml.BytesMarshaler.
+func (n yamlNumber) MarshalYAML() ([]byte, error) {
+	return []byte(n), nil
+}
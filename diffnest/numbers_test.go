@@ -0,0 +1,143 @@
+package diffnest
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestParse_NumberLiterals(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  string
+		content string
+		want    map[string]any
+	}{
+		{
+			name:    "JSON",
+			format:  FormatJSON,
+			content: `{"id": 9007199254740993, "price": 1.50, "big": 123456789012345678901234567890, "exp": 1e400}`,
+			want: map[string]any{
+				"id":    json.Number("9007199254740993"),
+				"price": json.Number("1.50"),
+				"big":   json.Number("123456789012345678901234567890"),
+				"exp":   json.Number("1e400"),
+			},
+		},
+		{
+			name:    "YAML",
+			format:  FormatYAML,
+			content: "id: 9007199254740993\nprice: 1.50\nbig: 123456789012345678901234567890\nexp: 1e400\nhex: 0x1F\nquoted: \"12\"\n",
+			want: map[string]any{
+				"id":     json.Number("9007199254740993"),
+				"price":  json.Number("1.50"),
+				"big":    json.Number("123456789012345678901234567890"),
+				"exp":    json.Number("1e400"),
+				"hex":    uint64(31),
+				"quoted": "12",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			docs, err := ParseWithFormat(strings.NewReader(tt.content), tt.format)
+			if err != nil {
+				t.Fatalf("ParseWithFormat() error = %v", err)
+			}
+
+			for key, want := range tt.want {
+				if got := docs[0].Children[key].Value; got != want {
+					t.Errorf("%s = %#v, want %#v", key, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestDiffEngine_ExactNumbers(t *testing.T) {
+	tests := []struct {
+		name    string
+		format1 string
+		from    string
+		format2 string
+		to      string
+		want    DiffStatus
+	}{
+		{name: "Neighbouring 64-bit IDs", format1: FormatJSON, from: `9007199254740993`, format2: FormatJSON, to: `9007199254740992`, want: StatusModified},
+		{name: "Large integers", format1: FormatJSON, from: `123456789012345678901234567890`, format2: FormatJSON, to: `123456789012345678901234567891`, want: StatusModified},
+		{name: "Decimals", format1: FormatJSON, from: `0.30000000000000000001`, format2: FormatJSON, to: `0.3`, want: StatusModified},
+		{name: "Trailing zeros", format1: FormatJSON, from: `1.50`, format2: FormatJSON, to: `1.5`, want: StatusSame},
+		{name: "Integral float", format1: FormatJSON, from: `10`, format2: FormatJSON, to: `10.0`, want: StatusSame},
+		{name: "JSON and YAML", format1: FormatJSON, from: `9007199254740993`, format2: FormatYAML, to: "9007199254740993", want: StatusSame},
+		{name: "JSON and TOML float", format1: FormatJSON, from: `{"a": 0.1}`, format2: FormatTOML, to: "a = 0.1", want: StatusSame},
+		{name: "YAML hexadecimal", format1: FormatYAML, from: "31", format2: FormatYAML, to: "0x1F", want: StatusSame},
+		{name: "YAML changed hexadecimal", format1: FormatYAML, from: "0x10", format2: FormatYAML, to: "0x11", want: StatusModified},
+		{name: "YAML underscores", format1: FormatYAML, from: "1_000", format2: FormatYAML, to: "1000", want: StatusSame},
+		{name: "TOML file with itself", format1: FormatTOML, from: "a = nan\nb = inf\nc = -inf\n", format2: FormatTOML, to: "a = nan\nb = inf\nc = -inf\n", want: StatusSame},
+		{name: "Infinities of different sign", format1: FormatTOML, from: "a = inf", format2: FormatTOML, to: "a = -inf", want: StatusModified},
+		{name: "NaN and a number", format1: FormatTOML, from: "a = nan", format2: FormatTOML, to: "a = 1.0", want: StatusModified},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			from, err := ParseWithFormat(strings.NewReader(tt.from), tt.format1)
+			if err != nil {
+				t.Fatalf("ParseWithFormat() error = %v", err)
+			}
+			to, err := ParseWithFormat(strings.NewReader(tt.to), tt.format2)
+			if err != nil {
+				t.Fatalf("ParseWithFormat() error = %v", err)
+			}
+
+			if got := NewDiffEngine(DiffOptions{}).Compare(from[0], to[0]).Status; got != tt.want {
+				t.Errorf("Compare() status = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNumberLiterals_Output(t *testing.T) {
+	docs, err := ParseWithFormat(strings.NewReader(`{"id": 9007199254740993, "price": 1.50, "big": 123456789012345678901234567890}`), FormatJSON)
+	if err != nil {
+		t.Fatalf("ParseWithFormat() error = %v", err)
+	}
+
+	if got, want := jsonValue(docs[0]), `{"id": 9007199254740993, "price": 1.50, "big": 123456789012345678901234567890}`; got != want {
+		t.Errorf("jsonValue() = %s, want %s", got, want)
+	}
+
+	var yamlOut strings.Builder
+	if err := EncodeWithFormat(&yamlOut, docs, FormatYAML); err != nil {
+		t.Fatalf("EncodeWithFormat() error = %v", err)
+	}
+	if got, want := yamlOut.String(), "id: 9007199254740993\nprice: 1.50\nbig: 123456789012345678901234567890\n"; got != want {
+		t.Errorf("EncodeWithFormat(yaml) = %q, want %q", got, want)
+	}
+
+	if got := (&UnifiedFormatter{}).formatValue(docs[0].Children["id"]); got != "9007199254740993" {
+		t.Errorf("formatValue() = %s, want 9007199254740993", got)
+	}
+}
+
+func TestNumberLiterals_YAMLSpellings(t *testing.T) {
+	docs, err := ParseWithFormat(strings.NewReader("hex: 0x10\noctal: 0o17\nthousand: 1_000\nplain: 12\n"), FormatYAML)
+	if err != nil {
+		t.Fatalf("ParseWithFormat() error = %v", err)
+	}
+
+	// Values are shown as written and encoded to JSON as decoded
+	for key, want := range map[string][2]string{
+		"hex":      {"0x10", "16"},
+		"octal":    {"0o17", "15"},
+		"thousand": {"1_000", "1000"},
+		"plain":    {"12", "12"},
+	} {
+		if got := (&UnifiedFormatter{}).formatValue(docs[0].Children[key]); got != want[0] {
+			t.Errorf("formatValue(%s) = %s, want %s", key, got, want[0])
+		}
+		if got := jsonValue(docs[0].Children[key]); got != want[1] {
+			t.Errorf("jsonValue(%s) = %s, want %s", key, got, want[1])
+		}
+	}
+}
@@ -1,14 +1,22 @@
 package diffnest
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"path/filepath"
+	"sort"
 	"strings"
+	"time"
+	"unicode/utf8"
 
+	"github.com/BurntSushi/toml"
 	"github.com/goccy/go-yaml"
+	"github.com/goccy/go-yaml/ast"
+	yamlparser "github.com/goccy/go-yaml/parser"
+	"github.com/goccy/go-yaml/token"
 )
 
 // Format constants.
@@ -21,6 +29,7 @@ const (
 // Errors.
 var (
 	ErrUnsupportedFormat = errors.New("unsupported format")
+	errInvalidJSON       = errors.New("invalid JSON")
 )
 
 // Parser interface for different formats.
@@ -53,7 +62,7 @@ func ParseWithFormat(reader io.Reader, format string) ([]*StructuredData, error)
 	case FormatYAML:
 		parser = &YAMLParser{}
 	case FormatTOML:
-		return nil, fmt.Errorf("%w: TOML parser not implemented yet", ErrUnsupportedFormat)
+		parser = &TOMLParser{}
 	default:
 		return nil, fmt.Errorf("%w: %s", ErrUnsupportedFormat, format)
 	}
@@ -74,12 +83,18 @@ func (p *JSONParser) Format() string {
 }
 
 func (p *JSONParser) Parse(reader io.Reader) ([]*StructuredData, error) {
-	decoder := json.NewDecoder(reader)
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read content: %w", err)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.UseNumber()
+	index := newLineIndex(content)
 	results := make([]*StructuredData, 0, 1)
 
 	for {
-		var raw any
-		err := decoder.Decode(&raw)
+		structured, err := p.decodeValue(decoder, index)
 		if err == io.EOF {
 			break
 		}
@@ -87,13 +102,164 @@ func (p *JSONParser) Parse(reader io.Reader) ([]*StructuredData, error) {
 			return nil, fmt.Errorf("failed to decode JSON: %w", err)
 		}
 
-		structured := convertToStructured(raw, "json")
 		results = append(results, structured)
 	}
 
 	return results, nil
 }
 
+// decodeValue decodes the next JSON value token by token so that object key order
+// and the position of every value are preserved.
+func (p *JSONParser) decodeValue(decoder *json.Decoder, index *lineIndex) (*StructuredData, error) {
+	location := index.tokenLocation(decoder.InputOffset())
+
+	tok, err := decoder.Token()
+	if err != nil {
+		return nil, err //nolint:wrapcheck // io.EOF must be returned as is
+	}
+
+	delim, ok := tok.(json.Delim)
+	if !ok {
+		structured := convertToStructured(tok, FormatJSON)
+		structured.Meta.Location = location
+
+		return structured, nil
+	}
+
+	switch delim {
+	case '{':
+		result := &StructuredData{
+			Type:     TypeObject,
+			Children: make(map[string]*StructuredData),
+			Meta:     &Metadata{Format: FormatJSON, Location: location},
+		}
+
+		for decoder.More() {
+			keyLocation := index.tokenLocation(decoder.InputOffset())
+
+			keyTok, err := decoder.Token()
+			if err != nil {
+				return nil, unexpectedJSONEOF(err)
+			}
+
+			key, ok := keyTok.(string)
+			if !ok {
+				return nil, fmt.Errorf("%w: object key %v", errInvalidJSON, keyTok)
+			}
+
+			child, err := p.decodeValue(decoder, index)
+			if err != nil {
+				return nil, unexpectedJSONEOF(err)
+			}
+			child.Meta.Location = keyLocation
+
+			if _, exists := result.Children[key]; !exists {
+				result.Keys = append(result.Keys, key)
+			}
+			result.Children[key] = child
+		}
+
+		if err := p.consumeDelim(decoder, '}'); err != nil {
+			return nil, err
+		}
+
+		return result, nil
+
+	case '[':
+		result := &StructuredData{
+			Type:     TypeArray,
+			Elements: []*StructuredData{},
+			Meta:     &Metadata{Format: FormatJSON, Location: location},
+		}
+
+		for decoder.More() {
+			elem, err := p.decodeValue(decoder, index)
+			if err != nil {
+				return nil, unexpectedJSONEOF(err)
+			}
+
+			result.Elements = append(result.Elements, elem)
+		}
+
+		if err := p.consumeDelim(decoder, ']'); err != nil {
+			return nil, err
+		}
+
+		return result, nil
+
+	default:
+		return nil, fmt.Errorf("%w: unexpected delimiter %v", errInvalidJSON, delim)
+	}
+}
+
+// consumeDelim reads the closing delimiter of an object or array.
+func (p *JSONParser) consumeDelim(decoder *json.Decoder, want json.Delim) error {
+	tok, err := decoder.Token()
+	if err != nil {
+		return unexpectedJSONEOF(err)
+	}
+
+	if delim, ok := tok.(json.Delim); !ok || delim != want {
+		return fmt.Errorf("%w: expected %v, got %v", errInvalidJSON, want, tok)
+	}
+
+	return nil
+}
+
+// unexpectedJSONEOF converts io.EOF inside a value into io.ErrUnexpectedEOF.
+func unexpectedJSONEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+
+	return err
+}
+
+// lineIndex converts byte offsets in source content into line and column positions.
+type lineIndex struct {
+	content    []byte
+	lineStarts []int
+}
+
+func newLineIndex(content []byte) *lineIndex {
+	lineStarts := []int{0}
+	for i, c := range content {
+		if c == '\n' {
+			lineStarts = append(lineStarts, i+1)
+		}
+	}
+
+	return &lineIndex{content: content, lineStarts: lineStarts}
+}
+
+// location returns the 1-based line and column of offset. Columns count characters.
+func (idx *lineIndex) location(offset int) *Location {
+	line := sort.SearchInts(idx.lineStarts, offset+1) - 1
+
+	return &Location{
+		Line:   line + 1,
+		Column: utf8.RuneCount(idx.content[idx.lineStarts[line]:offset]) + 1,
+	}
+}
+
+// tokenLocation returns the location of the next JSON token after offset,
+// skipping whitespace and separators.
+func (idx *lineIndex) tokenLocation(offset int64) *Location {
+	pos := int(offset)
+	for pos < len(idx.content) {
+		switch idx.content[pos] {
+		case ' ', '\t', '\r', '\n', ',', ':':
+			pos++
+
+			continue
+		}
+
+		break
+	}
+
+	return idx.location(pos)
+}
+
 // YAMLParser implements Parser for YAML.
 type YAMLParser struct{}
 
@@ -107,28 +273,304 @@ func (p *YAMLParser) Parse(reader io.Reader) ([]*StructuredData, error) {
 		return nil, fmt.Errorf("failed to read content: %w", err)
 	}
 
-	docs := strings.Split(string(content), "\n---\n")
+	const separator = "\n---\n"
+	docs := strings.Split(string(content), separator)
 	results := make([]*StructuredData, 0, len(docs))
+	offset := 0
 
 	for _, doc := range docs {
-		doc = strings.TrimSpace(doc)
-		if doc == "" {
+		start := offset
+		offset += len(doc) + len(separator)
+
+		trimmed := strings.TrimSpace(doc)
+		if trimmed == "" {
 			continue
 		}
 
 		var raw any
-		err := yaml.Unmarshal([]byte(doc), &raw)
+		comments := yaml.CommentMap{}
+		err := yaml.UnmarshalWithOptions([]byte(trimmed), &raw, yaml.UseOrderedMap(), yaml.CommentToMap(comments))
 		if err != nil {
 			return nil, fmt.Errorf("failed to decode YAML: %w", err)
 		}
 
 		structured := convertToStructured(raw, "yaml")
+
+		if file, err := yamlparser.ParseBytes([]byte(trimmed), 0); err == nil && len(file.Docs) > 0 {
+			leading := strings.Index(doc, trimmed)
+			firstLine := strings.LastIndex(doc[:leading], "\n") + 1
+			annotator := &yamlAnnotator{
+				lineOffset:   strings.Count(string(content[:start+leading]), "\n"),
+				columnOffset: utf8.RuneCountInString(doc[firstLine:leading]),
+				comments:     comments,
+			}
+			annotator.annotate(structured, file.Docs[0].Body)
+		}
+
 		results = append(results, structured)
 	}
 
 	return results, nil
 }
 
+// yamlAnnotator records source positions and comments by walking the YAML AST alongside the decoded data.
+// Offsets translate positions within a single document into positions within the whole file.
+type yamlAnnotator struct {
+	lineOffset   int
+	columnOffset int
+	comments     yaml.CommentMap // Comments keyed by YAML path
+}
+
+func (l *yamlAnnotator) annotate(data *StructuredData, node ast.Node) {
+	if data == nil || node == nil {
+		return
+	}
+
+	for {
+		switch n := node.(type) {
+		case *ast.TagNode:
+			node = n.Value
+
+			continue
+		case *ast.AnchorNode:
+			node = n.Value
+
+			continue
+		}
+
+		break
+	}
+
+	if node == nil {
+		return
+	}
+
+	if data.Meta != nil {
+		if data.Meta.Location == nil {
+			data.Meta.Location = l.location(l.startToken(node))
+		}
+		data.Meta.Comments, data.Meta.yamlComments = l.commentsAt(node.GetPath())
+	}
+
+	switch n := node.(type) {
+	case *ast.IntegerNode, *ast.FloatNode:
+		// Keep the literal, which the decoder may round; other spellings are kept for display
+		if literal := n.GetToken().Value; data.Type == TypeNumber && isJSONNumber(literal) {
+			data.Value = json.Number(literal)
+		} else if data.Type == TypeNumber && data.Meta != nil {
+			data.Meta.Literal = literal
+		}
+	case *ast.StringNode:
+		// Plain numbers too large for int64 or float64 are decoded as strings
+		if tok := n.GetToken(); data.Type == TypeString && tok.Type == token.StringType && isJSONNumber(tok.Value) {
+			data.Type = TypeNumber
+			data.Value = json.Number(tok.Value)
+		}
+	case *ast.MappingNode:
+		if data.Type == TypeObject {
+			for _, value := range n.Values {
+				l.annotateMappingValue(data, value)
+			}
+		}
+	case *ast.MappingValueNode:
+		if data.Type == TypeObject {
+			l.annotateMappingValue(data, n)
+		}
+	case *ast.SequenceNode:
+		if data.Type == TypeArray {
+			for i, value := range n.Values {
+				if i < len(data.Elements) {
+					l.annotate(data.Elements[i], value)
+				}
+			}
+		}
+	}
+}
+
+// startToken returns the first token of node. Block mappings report their ':' token,
+// so the first key is used instead.
+func (l *yamlAnnotator) startToken(node ast.Node) *token.Token {
+	switch n := node.(type) {
+	case *ast.MappingNode:
+		if !n.IsFlowStyle && len(n.Values) > 0 {
+			return n.Values[0].Key.GetToken()
+		}
+	case *ast.MappingValueNode:
+		return n.Key.GetToken()
+	}
+
+	return node.GetToken()
+}
+
+// annotateMappingValue records the key position for an object member, then descends into its value.
+func (l *yamlAnnotator) annotateMappingValue(data *StructuredData, value *ast.MappingValueNode) {
+	scalar, ok := value.Key.(ast.ScalarNode)
+	if !ok {
+		return
+	}
+
+	child, ok := data.Children[fmt.Sprint(scalar.GetValue())]
+	if !ok {
+		return
+	}
+
+	if child.Meta != nil {
+		child.Meta.Location = l.location(value.Key.GetToken())
+	}
+	l.annotate(child, value.Value)
+}
+
+// commentsAt returns the head, line and foot comments attached to path, in that order,
+// both as text and as entries with their positions.
+func (l *yamlAnnotator) commentsAt(path string) ([]string, []*yaml.Comment) {
+	entries := append([]*yaml.Comment{}, l.comments[path]...)
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Position < entries[j].Position
+	})
+
+	var comments []string
+	for _, entry := range entries {
+		for _, text := range entry.Texts {
+			comments = append(comments, strings.TrimSpace(text))
+		}
+	}
+	if len(entries) == 0 {
+		entries = nil
+	}
+
+	return comments, entries
+}
+
+func (l *yamlAnnotator) location(tok *token.Token) *Location {
+	if tok == nil {
+		return nil
+	}
+
+	column := tok.Position.Column
+	if tok.Position.Line == 1 {
+		column += l.columnOffset
+	}
+
+	return &Location{
+		Line:   tok.Position.Line + l.lineOffset,
+		Column: column,
+	}
+}
+
+// TOMLParser implements Parser for TOML.
+type TOMLParser struct{}
+
+func (p *TOMLParser) Format() string {
+	return FormatTOML
+}
+
+func (p *TOMLParser) Parse(reader io.Reader) ([]*StructuredData, error) {
+	var raw map[string]any
+	meta, err := toml.NewDecoder(reader).Decode(&raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode TOML: %w", err)
+	}
+
+	structured := convertToStructured(normalizeTOMLValue(raw), FormatTOML)
+	applyTOMLKeyOrder(structured, "", tomlKeyOrder(meta.Keys()))
+
+	return []*StructuredData{structured}, nil
+}
+
+// tomlKeyOrder collects the order in which keys are defined, grouped by their parent table.
+func tomlKeyOrder(keys []toml.Key) map[string][]string {
+	order := make(map[string][]string)
+	seen := make(map[string]bool)
+
+	for _, key := range keys {
+		for i := range key {
+			parent := strings.Join(key[:i], "\x00")
+			id := parent + "\x00" + key[i]
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+			order[parent] = append(order[parent], key[i])
+		}
+	}
+
+	return order
+}
+
+// applyTOMLKeyOrder records the key order for every table, including tables inside arrays of tables.
+func applyTOMLKeyOrder(data *StructuredData, parent string, order map[string][]string) {
+	switch data.Type {
+	case TypeObject:
+		data.Keys = order[parent]
+		for key, child := range data.Children {
+			childParent := key
+			if parent != "" {
+				childParent = parent + "\x00" + key
+			}
+			applyTOMLKeyOrder(child, childParent, order)
+		}
+	case TypeArray:
+		for _, elem := range data.Elements {
+			applyTOMLKeyOrder(elem, parent, order)
+		}
+	}
+}
+
+// normalizeTOMLValue converts TOML specific values (arrays of tables and datetimes)
+// into the generic types understood by convertToStructured.
+func normalizeTOMLValue(raw any) any {
+	switch v := raw.(type) {
+	case map[string]any:
+		normalized := make(map[string]any, len(v))
+		for key, val := range v {
+			normalized[key] = normalizeTOMLValue(val)
+		}
+
+		return normalized
+
+	case []map[string]any:
+		normalized := make([]any, len(v))
+		for i, table := range v {
+			normalized[i] = normalizeTOMLValue(table)
+		}
+
+		return normalized
+
+	case []any:
+		normalized := make([]any, len(v))
+		for i, elem := range v {
+			normalized[i] = normalizeTOMLValue(elem)
+		}
+
+		return normalized
+
+	case time.Time:
+		return tomlDatetime(formatTOMLDatetime(v))
+
+	default:
+		return v
+	}
+}
+
+// tomlDatetime is a TOML datetime in its string form, kept apart from strings so
+// that it is written back as a datetime.
+type tomlDatetime string
+
+// formatTOMLDatetime formats TOML datetimes, keeping local dates and times in their original form.
+// The TOML decoder marks local values with dedicated time zone names.
+func formatTOMLDatetime(t time.Time) string {
+	switch t.Location().String() {
+	case "datetime-local":
+		return t.Format("2006-01-02T15:04:05.999999999")
+	case "date-local":
+		return t.Format(time.DateOnly)
+	case "time-local":
+		return t.Format("15:04:05.999999999")
+	default:
+		return t.Format(time.RFC3339Nano)
+	}
+}
+
 // convertToStructured converts raw data to StructuredData.
 func convertToStructured(raw any, format string) *StructuredData {
 	if raw == nil {
@@ -155,6 +597,13 @@ func convertToStructured(raw any, format string) *StructuredData {
 			Meta:  &Metadata{Format: format},
 		}
 
+	case json.Number:
+		return &StructuredData{
+			Type:  TypeNumber,
+			Value: v,
+			Meta:  &Metadata{Format: format},
+		}
+
 	case string:
 		return &StructuredData{
 			Type:  TypeString,
@@ -162,6 +611,13 @@ func convertToStructured(raw any, format string) *StructuredData {
 			Meta:  &Metadata{Format: format},
 		}
 
+	case tomlDatetime:
+		return &StructuredData{
+			Type:  TypeString,
+			Value: string(v),
+			Meta:  &Metadata{Format: format, Datetime: true},
+		}
+
 	case []any:
 		elements := make([]*StructuredData, len(v))
 		for i, elem := range v {
@@ -189,15 +645,22 @@ func convertToStructured(raw any, format string) *StructuredData {
 	default:
 		if ms, ok := v.(yaml.MapSlice); ok {
 			children := make(map[string]*StructuredData)
+			keys := make([]string, 0, len(ms))
 			for _, item := range ms {
-				if key, ok := item.Key.(string); ok {
-					children[key] = convertToStructured(item.Value, format)
+				key, ok := item.Key.(string)
+				if !ok {
+					key = fmt.Sprint(item.Key)
+				}
+				if _, exists := children[key]; !exists {
+					keys = append(keys, key)
 				}
+				children[key] = convertToStructured(item.Value, format)
 			}
 
 			return &StructuredData{
 				Type:     TypeObject,
 				Children: children,
+				Keys:     keys,
 				Meta:     &Metadata{Format: format},
 			}
 		}
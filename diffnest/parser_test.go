@@ -1,7 +1,9 @@
 package diffnest
 
 import (
+	"fmt"
 	"reflect"
+	"strconv"
 	"strings"
 	"testing"
 )
@@ -389,6 +391,355 @@ special\n\
 	}
 }
 
+func TestTOMLParser_Parse(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    []*StructuredData
+		wantErr bool
+	}{
+		{
+			name: "Simple TOML table",
+			input: `name = "John"
+age = 30
+active = true`,
+			want: []*StructuredData{
+				{
+					Type: TypeObject,
+					Children: map[string]*StructuredData{
+						"name":   {Type: TypeString, Value: "John", Meta: &Metadata{Format: FormatTOML}},
+						"age":    {Type: TypeNumber, Value: int64(30), Meta: &Metadata{Format: FormatTOML}},
+						"active": {Type: TypeBool, Value: true, Meta: &Metadata{Format: FormatTOML}},
+					},
+					Meta: &Metadata{Format: FormatTOML},
+				},
+			},
+		},
+		{
+			name: "Nested and inline tables",
+			input: `[server]
+host = "localhost"
+point = { x = 1, y = 2.5 }`,
+			want: []*StructuredData{
+				{
+					Type: TypeObject,
+					Children: map[string]*StructuredData{
+						"server": {
+							Type: TypeObject,
+							Children: map[string]*StructuredData{
+								"host": {Type: TypeString, Value: "localhost", Meta: &Metadata{Format: FormatTOML}},
+								"point": {
+									Type: TypeObject,
+									Children: map[string]*StructuredData{
+										"x": {Type: TypeNumber, Value: int64(1), Meta: &Metadata{Format: FormatTOML}},
+										"y": {Type: TypeNumber, Value: 2.5, Meta: &Metadata{Format: FormatTOML}},
+									},
+									Meta: &Metadata{Format: FormatTOML},
+								},
+							},
+							Meta: &Metadata{Format: FormatTOML},
+						},
+					},
+					Meta: &Metadata{Format: FormatTOML},
+				},
+			},
+		},
+		{
+			name: "Arrays and arrays of tables",
+			input: `ports = [80, 443]
+
+[[products]]
+name = "Hammer"
+
+[[products]]
+name = "Nail"`,
+			want: []*StructuredData{
+				{
+					Type: TypeObject,
+					Children: map[string]*StructuredData{
+						"ports": {
+							Type: TypeArray,
+							Elements: []*StructuredData{
+								{Type: TypeNumber, Value: int64(80), Meta: &Metadata{Format: FormatTOML}},
+								{Type: TypeNumber, Value: int64(443), Meta: &Metadata{Format: FormatTOML}},
+							},
+							Meta: &Metadata{Format: FormatTOML},
+						},
+						"products": {
+							Type: TypeArray,
+							Elements: []*StructuredData{
+								{
+									Type: TypeObject,
+									Children: map[string]*StructuredData{
+										"name": {Type: TypeString, Value: "Hammer", Meta: &Metadata{Format: FormatTOML}},
+									},
+									Meta: &Metadata{Format: FormatTOML},
+								},
+								{
+									Type: TypeObject,
+									Children: map[string]*StructuredData{
+										"name": {Type: TypeString, Value: "Nail", Meta: &Metadata{Format: FormatTOML}},
+									},
+									Meta: &Metadata{Format: FormatTOML},
+								},
+							},
+							Meta: &Metadata{Format: FormatTOML},
+						},
+					},
+					Meta: &Metadata{Format: FormatTOML},
+				},
+			},
+		},
+		{
+			name: "Datetimes",
+			input: `odt = 1979-05-27T07:32:00-08:00
+ldt = 1979-05-27T07:32:00
+ld = 1979-05-27
+lt = 07:32:00.5`,
+			want: []*StructuredData{
+				{
+					Type: TypeObject,
+					Children: map[string]*StructuredData{
+						"odt": {Type: TypeString, Value: "1979-05-27T07:32:00-08:00", Meta: &Metadata{Format: FormatTOML}},
+						"ldt": {Type: TypeString, Value: "1979-05-27T07:32:00", Meta: &Metadata{Format: FormatTOML}},
+						"ld":  {Type: TypeString, Value: "1979-05-27", Meta: &Metadata{Format: FormatTOML}},
+						"lt":  {Type: TypeString, Value: "07:32:00.5", Meta: &Metadata{Format: FormatTOML}},
+					},
+					Meta: &Metadata{Format: FormatTOML},
+				},
+			},
+		},
+		{
+			name:    "Invalid TOML",
+			input:   `invalid = [`,
+			wantErr: true,
+		},
+	}
+
+	parser := &TOMLParser{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parser.Parse(strings.NewReader(tt.input))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("TOMLParser.Parse() error = %v, wantErr %v", err, tt.wantErr)
+
+				return
+			}
+			if !tt.wantErr && !equalStructuredDataSlice(got, tt.want) {
+				t.Errorf("TOMLParser.Parse() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseWithFormat_KeyOrder(t *testing.T) {
+	tests := []struct {
+		name      string
+		content   string
+		format    string
+		wantKeys  []string
+		wantInner []string
+	}{
+		{
+			name:      "JSON",
+			content:   `{"zeta": 1, "alpha": {"y": 1, "b": 2}, "mid": 3}`,
+			format:    FormatJSON,
+			wantKeys:  []string{"zeta", "alpha", "mid"},
+			wantInner: []string{"y", "b"},
+		},
+		{
+			name: "YAML",
+			content: `zeta: 1
+alpha:
+  y: 1
+  b: 2
+mid: 3`,
+			format:    FormatYAML,
+			wantKeys:  []string{"zeta", "alpha", "mid"},
+			wantInner: []string{"y", "b"},
+		},
+		{
+			name: "TOML",
+			content: `zeta = 1
+mid = 3
+
+[alpha]
+y = 1
+b = 2`,
+			format:    FormatTOML,
+			wantKeys:  []string{"zeta", "mid", "alpha"},
+			wantInner: []string{"y", "b"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			docs, err := ParseWithFormat(strings.NewReader(tt.content), tt.format)
+			if err != nil {
+				t.Fatalf("ParseWithFormat() error = %v", err)
+			}
+
+			if got := docs[0].ChildKeys(); !reflect.DeepEqual(got, tt.wantKeys) {
+				t.Errorf("ChildKeys() = %v, want %v", got, tt.wantKeys)
+			}
+			if got := docs[0].Children["alpha"].ChildKeys(); !reflect.DeepEqual(got, tt.wantInner) {
+				t.Errorf("nested ChildKeys() = %v, want %v", got, tt.wantInner)
+			}
+		})
+	}
+}
+
+func TestParseWithFormat_Location(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		format  string
+		want    map[string]Location // Dotted path -> expected location
+	}{
+		{
+			name: "JSON",
+			content: `{
+  "name": "app",
+  "ports": [80, {"port": 443}]
+}
+{"next": true}`,
+			format: FormatJSON,
+			want: map[string]Location{
+				"0":                {Line: 1, Column: 1},
+				"0.name":           {Line: 2, Column: 3},
+				"0.ports":          {Line: 3, Column: 3},
+				"0.ports.[0]":      {Line: 3, Column: 13},
+				"0.ports.[1]":      {Line: 3, Column: 17},
+				"0.ports.[1].port": {Line: 3, Column: 18},
+				"1":                {Line: 5, Column: 1},
+				"1.next":           {Line: 5, Column: 2},
+			},
+		},
+		{
+			name: "YAML with multiple documents",
+			content: `name: app
+ports:
+  - 80
+  - port: 443
+---
+
+next: true`,
+			format: FormatYAML,
+			want: map[string]Location{
+				"0":                {Line: 1, Column: 1},
+				"0.name":           {Line: 1, Column: 1},
+				"0.ports":          {Line: 2, Column: 1},
+				"0.ports.[0]":      {Line: 3, Column: 5},
+				"0.ports.[1]":      {Line: 4, Column: 5},
+				"0.ports.[1].port": {Line: 4, Column: 5},
+				"1":                {Line: 7, Column: 1},
+				"1.next":           {Line: 7, Column: 1},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			docs, err := ParseWithFormat(strings.NewReader(tt.content), tt.format)
+			if err != nil {
+				t.Fatalf("ParseWithFormat() error = %v", err)
+			}
+
+			got := make(map[string]Location)
+			for i, doc := range docs {
+				collectLocations(doc, strconv.Itoa(i), got)
+			}
+
+			for path, want := range tt.want {
+				if loc, ok := got[path]; !ok || loc != want {
+					t.Errorf("location of %s = %v, want %v", path, loc, want)
+				}
+			}
+		})
+	}
+}
+
+func TestYAMLParser_Comments(t *testing.T) {
+	input := `# head of name
+name: app # line of name
+settings:
+  # head of debug
+  debug: true
+ports:
+  - 80 # http
+`
+
+	docs, err := (&YAMLParser{}).Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("YAMLParser.Parse() error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		data *StructuredData
+		want []string
+	}{
+		{
+			name: "Head and line comments",
+			data: docs[0].Children["name"],
+			want: []string{"head of name", "line of name"},
+		},
+		{
+			name: "Nested head comment",
+			data: docs[0].Children["settings"].Children["debug"],
+			want: []string{"head of debug"},
+		},
+		{
+			name: "Array element line comment",
+			data: docs[0].Children["ports"].Elements[0],
+			want: []string{"http"},
+		},
+		{
+			name: "No comments",
+			data: docs[0].Children["settings"],
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.data.Meta.Comments; !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Comments = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func collectLocations(data *StructuredData, path string, locations map[string]Location) {
+	if data.Meta != nil && data.Meta.Location != nil {
+		locations[path] = *data.Meta.Location
+	}
+	for key, child := range data.Children {
+		collectLocations(child, path+"."+key, locations)
+	}
+	for i, elem := range data.Elements {
+		collectLocations(elem, fmt.Sprintf("%s.[%d]", path, i), locations)
+	}
+}
+
+func TestStructuredData_ChildKeys(t *testing.T) {
+	data := &StructuredData{
+		Type: TypeObject,
+		Children: map[string]*StructuredData{
+			"b": {Type: TypeNull},
+			"a": {Type: TypeNull},
+			"d": {Type: TypeNull},
+			"c": {Type: TypeNull},
+		},
+		Keys: []string{"d", "b", "unknown"},
+	}
+
+	want := []string{"d", "b", "a", "c"}
+	if got := data.ChildKeys(); !reflect.DeepEqual(got, want) {
+		t.Errorf("ChildKeys() = %v, want %v", got, want)
+	}
+}
+
 func TestParseWithFormat(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -422,9 +773,15 @@ func TestParseWithFormat(t *testing.T) {
 			wantErr: true, // JSON cannot parse YAML
 		},
 		{
-			name:    "TOML format (not implemented)",
+			name:    "TOML with TOML format",
 			content: `test = "value"`,
 			format:  FormatTOML,
+			wantLen: 1,
+		},
+		{
+			name:    "YAML with TOML format",
+			content: `test: value`,
+			format:  FormatTOML,
 			wantErr: true,
 		},
 		{
@@ -0,0 +1,436 @@
+package diffnest
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// JSON Patch operation names.
+const (
+	PatchOpAdd     = "add"
+	PatchOpRemove  = "remove"
+	PatchOpReplace = "replace"
+	PatchOpMove    = "move"
+	PatchOpCopy    = "copy"
+	PatchOpTest    = "test"
+)
+
+// Patch types accepted by ApplyPatchDocument.
+const (
+	PatchTypeJSONPatch  = "json-patch"
+	PatchTypeMergePatch = "merge-patch"
+)
+
+// Patch errors.
+var (
+	ErrUnsupportedPatchType = errors.New("unsupported patch type")
+	ErrInvalidPatch         = errors.New("invalid patch")
+	ErrPatchPathNotFound    = errors.New("path not found")
+	ErrPatchTestFailed      = errors.New("test failed")
+)
+
+// PatchOperation is a single RFC 6902 JSON Patch operation.
+type PatchOperation struct {
+	Op    string
+	Path  string
+	From  string          // Source pointer for move and copy
+	Value *StructuredData // Value for add, replace and test
+}
+
+// ParsePatch reads JSON Patch operations from a parsed patch document.
+func ParsePatch(patch *StructuredData) ([]PatchOperation, error) {
+	if patch == nil || patch.Type != TypeArray {
+		return nil, fmt.Errorf("%w: JSON Patch must be an array of operations", ErrInvalidPatch)
+	}
+
+	ops := make([]PatchOperation, 0, len(patch.Elements))
+	for i, elem := range patch.Elements {
+		op, err := parsePatchOperation(elem)
+		if err != nil {
+			return nil, fmt.Errorf("operation [%d]: %w", i, err)
+		}
+		ops = append(ops, op)
+	}
+
+	return ops, nil
+}
+
+func parsePatchOperation(elem *StructuredData) (PatchOperation, error) {
+	var op PatchOperation
+
+	if elem.Type != TypeObject {
+		return op, fmt.Errorf("%w: operation must be an object", ErrInvalidPatch)
+	}
+
+	member := func(name string, required bool) (string, error) {
+		value, ok := elem.Children[name]
+		if !ok {
+			if required {
+				return "", fmt.Errorf("%w: missing %q", ErrInvalidPatch, name)
+			}
+
+			return "", nil
+		}
+		if value.Type != TypeString {
+			return "", fmt.Errorf("%w: %q must be a string", ErrInvalidPatch, name)
+		}
+
+		return fmt.Sprint(value.Value), nil
+	}
+
+	var err error
+	if op.Op, err = member("op", true); err != nil {
+		return op, err
+	}
+	if op.Path, err = member("path", true); err != nil {
+		return op, err
+	}
+
+	switch op.Op {
+	case PatchOpAdd, PatchOpReplace, PatchOpTest:
+		value, ok := elem.Children["value"]
+		if !ok {
+			return op, fmt.Errorf("%w: missing %q", ErrInvalidPatch, "value")
+		}
+		op.Value = value
+	case PatchOpMove, PatchOpCopy:
+		if op.From, err = member("from", true); err != nil {
+			return op, err
+		}
+	case PatchOpRemove:
+	default:
+		return op, fmt.Errorf("%w: unknown op %q", ErrInvalidPatch, op.Op)
+	}
+
+	return op, nil
+}
+
+// ApplyPatchDocument applies a parsed patch document of the given type to doc.
+// An empty patch type selects JSON Patch for arrays and JSON Merge Patch for anything else.
+func ApplyPatchDocument(doc, patch *StructuredData, patchType string) (*StructuredData, error) {
+	if patchType == "" {
+		patchType = PatchTypeMergePatch
+		if patch != nil && patch.Type == TypeArray {
+			patchType = PatchTypeJSONPatch
+		}
+	}
+
+	switch patchType {
+	case PatchTypeJSONPatch:
+		ops, err := ParsePatch(patch)
+		if err != nil {
+			return nil, err
+		}
+
+		return ApplyPatch(doc, ops)
+	case PatchTypeMergePatch:
+		return ApplyMergePatch(doc, patch), nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedPatchType, patchType)
+	}
+}
+
+// ApplyPatch applies JSON Patch operations to doc and returns the patched document.
+// The operations apply to a copy, so doc is left untouched when any of them fails.
+func ApplyPatch(doc *StructuredData, ops []PatchOperation) (*StructuredData, error) {
+	result := cloneStructured(doc)
+
+	for i, op := range ops {
+		var err error
+		if result, err = applyPatchOperation(result, op); err != nil {
+			return nil, fmt.Errorf("operation [%d] (%s %q): %w", i, op.Op, op.Path, err)
+		}
+	}
+
+	return result, nil
+}
+
+func applyPatchOperation(doc *StructuredData, op PatchOperation) (*StructuredData, error) {
+	path, err := parseJSONPointer(op.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch op.Op {
+	case PatchOpAdd:
+		return addAt(doc, path, cloneStructured(op.Value))
+
+	case PatchOpRemove:
+		if _, err := removeAt(doc, path); err != nil {
+			return nil, err
+		}
+
+		return doc, nil
+
+	case PatchOpReplace:
+		return replaceAt(doc, path, cloneStructured(op.Value))
+
+	case PatchOpMove:
+		from, err := parseJSONPointer(op.From)
+		if err != nil {
+			return nil, err
+		}
+		if len(from) < len(path) && slices.Equal(from, path[:len(from)]) {
+			return nil, fmt.Errorf("%w: cannot move %q into its own child", ErrInvalidPatch, op.From)
+		}
+		if slices.Equal(from, path) {
+			_, err := resolvePointer(doc, path)
+
+			return doc, err
+		}
+		value, err := removeAt(doc, from)
+		if err != nil {
+			return nil, err
+		}
+
+		return addAt(doc, path, value)
+
+	case PatchOpCopy:
+		from, err := parseJSONPointer(op.From)
+		if err != nil {
+			return nil, err
+		}
+		value, err := resolvePointer(doc, from)
+		if err != nil {
+			return nil, err
+		}
+
+		return addAt(doc, path, cloneStructured(value))
+
+	case PatchOpTest:
+		value, err := resolvePointer(doc, path)
+		if err != nil {
+			return nil, err
+		}
+		if NewDiffEngine(DiffOptions{ArrayDiffStrategy: ArrayStrategyIndex}).Compare(value, op.Value).Status != StatusSame {
+			return nil, fmt.Errorf("%w: value is %s, want %s", ErrPatchTestFailed, jsonValue(value), jsonValue(op.Value))
+		}
+
+		return doc, nil
+	}
+
+	return nil, fmt.Errorf("%w: unknown op %q", ErrInvalidPatch, op.Op)
+}
+
+// ApplyMergePatch applies an RFC 7386 JSON Merge Patch to doc and returns the patched document.
+// Null members of patch remove keys, objects merge recursively and other values replace.
+func ApplyMergePatch(doc, patch *StructuredData) *StructuredData {
+	if patch == nil || patch.Type != TypeObject {
+		return cloneStructured(patch)
+	}
+
+	var result *StructuredData
+	if doc != nil && doc.Type == TypeObject {
+		result = cloneStructured(doc)
+	} else {
+		result = &StructuredData{Type: TypeObject, Children: make(map[string]*StructuredData), Meta: patch.Meta}
+	}
+
+	for _, key := range patch.ChildKeys() {
+		value := patch.Children[key]
+		if value.Type == TypeNull {
+			removeChild(result, key)
+
+			continue
+		}
+		setChild(result, key, ApplyMergePatch(result.Children[key], value))
+	}
+
+	return result
+}
+
+// parseJSONPointer splits an RFC 6901 JSON Pointer into unescaped reference tokens.
+func parseJSONPointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("%w: pointer %q must start with \"/\"", ErrInvalidPatch, pointer)
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+	unescape := strings.NewReplacer("~1", "/", "~0", "~")
+	for i, token := range tokens {
+		tokens[i] = unescape.Replace(token)
+	}
+
+	return tokens, nil
+}
+
+// formatJSONPointer joins reference tokens into a JSON Pointer.
+func formatJSONPointer(tokens []string) string {
+	var b strings.Builder
+	for _, token := range tokens {
+		b.WriteString("/" + escapeJSONPointer(token))
+	}
+
+	return b.String()
+}
+
+// resolvePointer returns the value at path.
+func resolvePointer(doc *StructuredData, path []string) (*StructuredData, error) {
+	current := doc
+	for i, token := range path {
+		switch current.Type {
+		case TypeObject:
+			child, ok := current.Children[token]
+			if !ok {
+				return nil, fmt.Errorf("%w: %s", ErrPatchPathNotFound, formatJSONPointer(path[:i+1]))
+			}
+			current = child
+		case TypeArray:
+			index, err := arrayIndex(token, len(current.Elements), false)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", formatJSONPointer(path[:i+1]), err)
+			}
+			current = current.Elements[index]
+		default:
+			return nil, fmt.Errorf("%w: %s is not an object or array", ErrPatchPathNotFound, formatJSONPointer(path[:i]))
+		}
+	}
+
+	return current, nil
+}
+
+// addAt adds value at path and returns the resulting document.
+// Adding to an array inserts before the index, "-" appends.
+func addAt(doc *StructuredData, path []string, value *StructuredData) (*StructuredData, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+
+	parent, err := resolvePointer(doc, path[:len(path)-1])
+	if err != nil {
+		return nil, err
+	}
+
+	token := path[len(path)-1]
+	switch parent.Type {
+	case TypeObject:
+		setChild(parent, token, value)
+	case TypeArray:
+		index, err := arrayIndex(token, len(parent.Elements), true)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", formatJSONPointer(path), err)
+		}
+		parent.Elements = slices.Insert(parent.Elements, index, value)
+	default:
+		return nil, fmt.Errorf("%w: %s is not an object or array", ErrPatchPathNotFound, formatJSONPointer(path[:len(path)-1]))
+	}
+
+	return doc, nil
+}
+
+// replaceAt replaces the existing value at path, keeping its position, and returns the resulting document.
+func replaceAt(doc *StructuredData, path []string, value *StructuredData) (*StructuredData, error) {
+	if _, err := resolvePointer(doc, path); err != nil {
+		return nil, err
+	}
+	if len(path) == 0 {
+		return value, nil
+	}
+
+	parent, _ := resolvePointer(doc, path[:len(path)-1]) //nolint:errcheck // Resolved above
+	token := path[len(path)-1]
+	if parent.Type == TypeObject {
+		parent.Children[token] = value
+	} else {
+		index, _ := arrayIndex(token, len(parent.Elements), false) //nolint:errcheck // Resolved above
+		parent.Elements[index] = value
+	}
+
+	return doc, nil
+}
+
+// removeAt removes the value at path and returns it.
+func removeAt(doc *StructuredData, path []string) (*StructuredData, error) {
+	if len(path) == 0 {
+		return nil, fmt.Errorf("%w: cannot remove the whole document", ErrInvalidPatch)
+	}
+
+	value, err := resolvePointer(doc, path)
+	if err != nil {
+		return nil, err
+	}
+
+	parent, _ := resolvePointer(doc, path[:len(path)-1]) //nolint:errcheck // Resolved above
+	token := path[len(path)-1]
+	if parent.Type == TypeObject {
+		removeChild(parent, token)
+	} else {
+		index, _ := arrayIndex(token, len(parent.Elements), false) //nolint:errcheck // Resolved above
+		parent.Elements = slices.Delete(parent.Elements, index, index+1)
+	}
+
+	return value, nil
+}
+
+// arrayIndex parses an array index token. With allowEnd, "-" and the length itself address the end of the array.
+func arrayIndex(token string, length int, allowEnd bool) (int, error) {
+	if token == "-" && allowEnd {
+		return length, nil
+	}
+
+	// RFC 6901 indices are plain decimal numbers without sign or leading zeros
+	index, err := strconv.Atoi(token)
+	if err != nil || token[0] < '0' || token[0] > '9' || (len(token) > 1 && token[0] == '0') {
+		return 0, fmt.Errorf("%w: invalid array index %q", ErrPatchPathNotFound, token)
+	}
+
+	if index > length || (index == length && !allowEnd) {
+		return 0, fmt.Errorf("%w: index %d out of range for array of length %d", ErrPatchPathNotFound, index, length)
+	}
+
+	return index, nil
+}
+
+// setChild sets a member of an object, keeping the position of existing keys.
+func setChild(data *StructuredData, key string, value *StructuredData) {
+	if _, exists := data.Children[key]; !exists {
+		data.Keys = append(data.ChildKeys(), key)
+	}
+	data.Children[key] = value
+}
+
+// removeChild removes a member of an object.
+func removeChild(data *StructuredData, key string) {
+	if _, exists := data.Children[key]; !exists {
+		return
+	}
+
+	data.Keys = slices.DeleteFunc(data.ChildKeys(), func(k string) bool { return k == key })
+	delete(data.Children, key)
+}
+
+// cloneStructured returns a deep copy of data.
+func cloneStructured(data *StructuredData) *StructuredData {
+	if data == nil {
+		return nil
+	}
+
+	clone := *data
+	if data.Meta != nil {
+		meta := *data.Meta
+		clone.Meta = &meta
+	}
+	if data.Children != nil {
+		clone.Children = make(map[string]*StructuredData, len(data.Children))
+		for key, child := range data.Children {
+			clone.Children[key] = cloneStructured(child)
+		}
+	}
+	if data.Keys != nil {
+		clone.Keys = slices.Clone(data.Keys)
+	}
+	if data.Elements != nil {
+		clone.Elements = make([]*StructuredData, len(data.Elements))
+		for i, elem := range data.Elements {
+			clone.Elements[i] = cloneStructured(elem)
+		}
+	}
+
+	return &clone
+}
@@ -0,0 +1,166 @@
+package diffnest
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestApplyPatch(t *testing.T) {
+	const doc = `{"name": "app", "tags": ["a", "b"], "spec": {"a/b": 1, "m~n": 2}}`
+
+	tests := []struct {
+		name    string
+		patch   string
+		want    string
+		wantErr error
+	}{
+		{
+			name:  "Add member and append element",
+			patch: `[{"op": "add", "path": "/port", "value": 80}, {"op": "add", "path": "/tags/-", "value": "c"}]`,
+			want:  `{"name": "app", "tags": ["a", "b", "c"], "spec": {"a/b": 1, "m~n": 2}, "port": 80}`,
+		},
+		{
+			name:  "Insert before index",
+			patch: `[{"op": "add", "path": "/tags/0", "value": "z"}]`,
+			want:  `{"name": "app", "tags": ["z", "a", "b"], "spec": {"a/b": 1, "m~n": 2}}`,
+		},
+		{
+			name:  "Escaped pointers",
+			patch: `[{"op": "remove", "path": "/spec/a~1b"}, {"op": "replace", "path": "/spec/m~0n", "value": 3}]`,
+			want:  `{"name": "app", "tags": ["a", "b"], "spec": {"m~n": 3}}`,
+		},
+		{
+			name:  "Replace keeps key position",
+			patch: `[{"op": "replace", "path": "/name", "value": "web"}]`,
+			want:  `{"name": "web", "tags": ["a", "b"], "spec": {"a/b": 1, "m~n": 2}}`,
+		},
+		{
+			name:  "Move and copy",
+			patch: `[{"op": "move", "from": "/tags/0", "path": "/tags/1"}, {"op": "copy", "from": "/tags", "path": "/labels"}]`,
+			want:  `{"name": "app", "tags": ["b", "a"], "spec": {"a/b": 1, "m~n": 2}, "labels": ["b", "a"]}`,
+		},
+		{
+			name:  "Passing test",
+			patch: `[{"op": "test", "path": "/tags", "value": ["a", "b"]}]`,
+			want:  doc,
+		},
+		{
+			name:    "Failing test",
+			patch:   `[{"op": "remove", "path": "/name"}, {"op": "test", "path": "/spec/a~1b", "value": 2}]`,
+			wantErr: ErrPatchTestFailed,
+		},
+		{
+			name:    "Missing member",
+			patch:   `[{"op": "remove", "path": "/spec/missing"}]`,
+			wantErr: ErrPatchPathNotFound,
+		},
+		{
+			name:    "Index out of range",
+			patch:   `[{"op": "replace", "path": "/tags/2", "value": "c"}]`,
+			wantErr: ErrPatchPathNotFound,
+		},
+		{
+			name:    "Leading zero index",
+			patch:   `[{"op": "add", "path": "/tags/01", "value": "c"}]`,
+			wantErr: ErrPatchPathNotFound,
+		},
+		{
+			name:    "Move into own child",
+			patch:   `[{"op": "move", "from": "/spec", "path": "/spec/inner"}]`,
+			wantErr: ErrInvalidPatch,
+		},
+		{
+			name:    "Unknown operation",
+			patch:   `[{"op": "merge", "path": "/name"}]`,
+			wantErr: ErrInvalidPatch,
+		},
+		{
+			name:    "Missing value",
+			patch:   `[{"op": "add", "path": "/name"}]`,
+			wantErr: ErrInvalidPatch,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			original := parseJSONDocument(t, doc)
+			got, err := ApplyPatchDocument(original, parseJSONDocument(t, tt.patch), PatchTypeJSONPatch)
+
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("ApplyPatchDocument() error = %v, want %v", err, tt.wantErr)
+				}
+			} else {
+				if err != nil {
+					t.Fatalf("ApplyPatchDocument() error = %v", err)
+				}
+				if want := parseJSONDocument(t, tt.want); jsonValue(got) != jsonValue(want) {
+					t.Errorf("ApplyPatchDocument() = %s, want %s", jsonValue(got), jsonValue(want))
+				}
+			}
+
+			// The original document is never modified
+			if want := parseJSONDocument(t, doc); jsonValue(original) != jsonValue(want) {
+				t.Errorf("original document modified to %s", jsonValue(original))
+			}
+		})
+	}
+}
+
+func TestApplyMergePatch(t *testing.T) {
+	tests := []struct {
+		name  string
+		doc   string
+		patch string
+		want  string
+	}{
+		{
+			name:  "Merge nested objects",
+			doc:   `{"a": {"b": 1, "c": 2}, "d": 3}`,
+			patch: `{"a": {"c": 4, "e": 5}}`,
+			want:  `{"a": {"b": 1, "c": 4, "e": 5}, "d": 3}`,
+		},
+		{
+			name:  "Null removes member",
+			doc:   `{"a": 1, "b": 2}`,
+			patch: `{"a": null, "x": null}`,
+			want:  `{"b": 2}`,
+		},
+		{
+			name:  "Arrays are replaced",
+			doc:   `{"a": [1, 2]}`,
+			patch: `{"a": [3]}`,
+			want:  `{"a": [3]}`,
+		},
+		{
+			name:  "Object replaces scalar",
+			doc:   `{"a": "x"}`,
+			patch: `{"a": {"b": null, "c": 1}}`,
+			want:  `{"a": {"c": 1}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ApplyPatchDocument(parseJSONDocument(t, tt.doc), parseJSONDocument(t, tt.patch), "")
+			if err != nil {
+				t.Fatalf("ApplyPatchDocument() error = %v", err)
+			}
+			if want := parseJSONDocument(t, tt.want); jsonValue(got) != jsonValue(want) {
+				t.Errorf("ApplyPatchDocument() = %s, want %s", jsonValue(got), jsonValue(want))
+			}
+		})
+	}
+}
+
+func parseJSONDocument(t *testing.T, content string) *StructuredData {
+	t.Helper()
+
+	docs, err := (&JSONParser{}).Parse(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	return docs[0]
+}
@@ -0,0 +1,319 @@
+package diffnest
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidPathPattern is returned for malformed path patterns.
+var ErrInvalidPathPattern = errors.New("invalid path pattern")
+
+// PathPattern matches paths of compared values, written like the paths of the
+// paths format:
+//
+//	metadata.resourceVersion     a single key
+//	status                       a top-level key and everything below it
+//	**.lastTransitionTime        the key at any depth
+//	spec.*.image                 any key or array element in between
+//	spec.containers[*].image     any array element
+//	spec.containers[0]           the first element
+//	spec.containers[name=web]    elements whose name is web on both sides
+//	["app.kubernetes.io/name"]   a key that is not a plain word
+//
+// Keys may contain the wildcards of path.Match, such as last*Time.
+type PathPattern struct {
+	source   string
+	segments []patternSegment
+}
+
+// patternSegment is one key, wildcard or array selector of a PathPattern.
+type patternSegment struct {
+	kind  patternKind
+	key   string // Key glob, or key of a field selector
+	value string // Value of a field selector
+	index int    // Index of an index selector
+}
+
+type patternKind int
+
+const (
+	patternKey      patternKind = iota // name or glob
+	patternAny                         // *, any key or element
+	patternAnyDepth                    // **, any number of keys or elements
+	patternElement                     // [*]
+	patternIndex                       // [0]
+	patternField                       // [name=web]
+)
+
+// Match results of PathPattern.match, combined as bits.
+const (
+	matchExact  = 1 << iota // The pattern matches the path
+	matchInside             // The pattern matches an ancestor of the path
+	matchBelow              // The pattern may match a descendant of the path
+)
+
+// ParsePathPattern parses a path pattern.
+func ParsePathPattern(pattern string) (PathPattern, error) {
+	segments, err := parsePatternSegments(pattern)
+	if err != nil {
+		return PathPattern{}, fmt.Errorf("%w %q: %w", ErrInvalidPathPattern, pattern, err)
+	}
+
+	return PathPattern{source: pattern, segments: segments}, nil
+}
+
+// MustParsePathPattern is like ParsePathPattern but panics on invalid patterns.
+func MustParsePathPattern(pattern string) PathPattern {
+	p, err := ParsePathPattern(pattern)
+	if err != nil {
+		panic(err)
+	}
+
+	return p
+}
+
+// ReadPathPatterns reads path patterns from r, one per line. Blank lines and
+// lines starting with # are skipped.
+func ReadPathPatterns(r io.Reader) ([]PathPattern, error) {
+	var patterns []PathPattern
+
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		pattern, err := ParsePathPattern(text)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		patterns = append(patterns, pattern)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read path patterns: %w", err)
+	}
+
+	return patterns, nil
+}
+
+// String returns the pattern as written.
+func (p PathPattern) String() string {
+	return p.source
+}
+
+func parsePatternSegments(pattern string) ([]patternSegment, error) {
+	var segments []patternSegment
+	rest := pattern
+	expectKey := true
+
+	for rest != "" {
+		switch {
+		case strings.HasPrefix(rest, `["`):
+			key, n, err := unquotePatternKey(rest)
+			if err != nil {
+				return nil, err
+			}
+			segments = append(segments, patternSegment{kind: patternKey, key: key})
+			rest = rest[n:]
+		case rest[0] == '[':
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return nil, errors.New("unclosed [")
+			}
+			segment, err := parseSelector(rest[1:end])
+			if err != nil {
+				return nil, err
+			}
+			segments = append(segments, segment)
+			rest = rest[end+1:]
+		case expectKey:
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+			segment, err := parseKey(rest[:end])
+			if err != nil {
+				return nil, err
+			}
+			segments = append(segments, segment)
+			rest = rest[end:]
+		default:
+			return nil, fmt.Errorf("unexpected %q", rest)
+		}
+
+		expectKey = false
+		if strings.HasPrefix(rest, ".") {
+			rest = rest[1:]
+			if rest == "" {
+				return nil, errors.New("trailing .")
+			}
+			expectKey = true
+		}
+	}
+
+	if len(segments) == 0 {
+		return nil, errors.New("empty pattern")
+	}
+
+	return segments, nil
+}
+
+func parseKey(key string) (patternSegment, error) {
+	switch key {
+	case "":
+		return patternSegment{}, errors.New("empty key")
+	case "*":
+		return patternSegment{kind: patternAny}, nil
+	case "**":
+		return patternSegment{kind: patternAnyDepth}, nil
+	}
+
+	if _, err := path.Match(key, ""); err != nil {
+		return patternSegment{}, fmt.Errorf("key %q: %w", key, err)
+	}
+
+	return patternSegment{kind: patternKey, key: key}, nil
+}
+
+func parseSelector(selector string) (patternSegment, error) {
+	if selector == "*" {
+		return patternSegment{kind: patternElement}, nil
+	}
+
+	if key, value, ok := strings.Cut(selector, "="); ok {
+		if key == "" {
+			return patternSegment{}, fmt.Errorf("selector [%s] has no key", selector)
+		}
+
+		return patternSegment{kind: patternField, key: key, value: value}, nil
+	}
+
+	index, err := strconv.Atoi(selector)
+	if err != nil || index < 0 {
+		return patternSegment{}, fmt.Errorf("invalid array selector [%s]", selector)
+	}
+
+	return patternSegment{kind: patternIndex, index: index}, nil
+}
+
+// unquotePatternKey reads a quoted key like ["a.b"] at the start of s and
+// returns it with the length it takes in s.
+func unquotePatternKey(s string) (string, int, error) {
+	quoted, err := strconv.QuotedPrefix(s[1:])
+	if err != nil || !strings.HasPrefix(s[1+len(quoted):], "]") {
+		return "", 0, errors.New("invalid quoted key")
+	}
+
+	key, err := strconv.Unquote(quoted)
+	if err != nil {
+		return "", 0, errors.New("invalid quoted key")
+	}
+
+	return key, len(quoted) + 2, nil
+}
+
+// match compares the pattern with path. nodes holds the compared values at each
+// depth of path, used by field selectors on array elements; it may be shorter
+// than path.
+func (p PathPattern) match(path []string, nodes [][2]*StructuredData) int {
+	return p.matchFrom(0, 0, path, nodes)
+}
+
+func (p PathPattern) matchFrom(i, j int, path []string, nodes [][2]*StructuredData) int {
+	if i == len(p.segments) {
+		if j == len(path) {
+			return matchExact
+		}
+
+		return matchInside
+	}
+
+	segment := p.segments[i]
+	if segment.kind == patternAnyDepth {
+		result := p.matchFrom(i+1, j, path, nodes)
+		if j < len(path) {
+			result |= p.matchFrom(i, j+1, path, nodes)
+		} else {
+			result |= matchBelow
+		}
+
+		return result
+	}
+
+	if j == len(path) {
+		return matchBelow
+	}
+
+	var node [2]*StructuredData
+	if j < len(nodes) {
+		node = nodes[j]
+	}
+	if !segment.matches(path[j], node) {
+		return 0
+	}
+
+	return p.matchFrom(i+1, j+1, path, nodes)
+}
+
+// matches reports whether the path segment of a value matches. node holds the
+// value on both sides, either of which may be nil.
+func (s patternSegment) matches(segment string, node [2]*StructuredData) bool {
+	isElement := strings.HasPrefix(segment, "[") && strings.HasSuffix(segment, "]")
+
+	switch s.kind {
+	case patternAny:
+		return true
+	case patternKey:
+		if isElement {
+			return false
+		}
+		ok, _ := path.Match(s.key, segment)
+
+		return ok
+	case patternElement:
+		return isElement
+	case patternIndex:
+		return segment == fmt.Sprintf("[%d]", s.index)
+	case patternField:
+		if !isElement {
+			return false
+		}
+		for _, part := range strings.Split(segment[1:len(segment)-1], ",") {
+			if part == s.key+"="+s.value {
+				return true
+			}
+		}
+
+		return s.matchesField(node)
+	case patternAnyDepth:
+	}
+
+	return false
+}
+
+// matchesField reports whether the element has the selected field value on every
+// side it exists, so elements paired by value are only selected as a whole.
+func (s patternSegment) matchesField(node [2]*StructuredData) bool {
+	found := false
+	for _, data := range node {
+		if data == nil {
+			continue
+		}
+		if data.Type != TypeObject {
+			return false
+		}
+		field, ok := data.Children[s.key]
+		if !ok || isContainer(field) || fmt.Sprint(field.Value) != s.value {
+			return false
+		}
+		found = true
+	}
+
+	return found
+}
@@ -0,0 +1,110 @@
+package diffnest
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParsePathPattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		wantErr bool
+	}{
+		{pattern: "metadata.resourceVersion"},
+		{pattern: "**.lastTransitionTime"},
+		{pattern: "spec.containers[*].image"},
+		{pattern: "spec.containers[0]"},
+		{pattern: "spec.containers[name=web].env[*]"},
+		{pattern: `metadata.labels["app.kubernetes.io/name"]`},
+		{pattern: "[0].name"},
+		{pattern: "", wantErr: true},
+		{pattern: "spec.", wantErr: true},
+		{pattern: "spec..image", wantErr: true},
+		{pattern: "items[", wantErr: true},
+		{pattern: "items[-1]", wantErr: true},
+		{pattern: "items[=web]", wantErr: true},
+		{pattern: "items[0]image", wantErr: true},
+		{pattern: `labels["a`, wantErr: true},
+		{pattern: "key[[", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			_, err := ParsePathPattern(tt.pattern)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParsePathPattern() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidPathPattern) {
+				t.Errorf("ParsePathPattern() error = %v, want ErrInvalidPathPattern", err)
+			}
+		})
+	}
+}
+
+func TestPathPattern_match(t *testing.T) {
+	web := parseJSONDocument(t, `{"name": "web"}`)
+
+	tests := []struct {
+		name    string
+		pattern string
+		path    []string
+		nodes   [][2]*StructuredData
+		want    int
+	}{
+		{name: "Exact key", pattern: "metadata.name", path: []string{"metadata", "name"}, want: matchExact},
+		{name: "Ancestor", pattern: "status", path: []string{"status", "phase"}, want: matchInside},
+		{name: "Descendant", pattern: "spec.template", path: []string{"spec"}, want: matchBelow},
+		{name: "Other key", pattern: "spec.template", path: []string{"status"}, want: 0},
+		{name: "Key glob", pattern: "last*Time", path: []string{"lastProbeTime"}, want: matchExact},
+		{name: "Any segment", pattern: "spec.*.image", path: []string{"spec", "[0]", "image"}, want: matchExact},
+		{name: "Any depth", pattern: "**.time", path: []string{"a", "[1]", "b", "time"}, want: matchExact | matchBelow},
+		{name: "Any depth at top", pattern: "**.time", path: []string{"time"}, want: matchExact | matchBelow},
+		{name: "Any element", pattern: "items[*]", path: []string{"items", "[3]"}, want: matchExact},
+		{name: "Key is no element", pattern: "items[*]", path: []string{"items", "name"}, want: 0},
+		{name: "Index", pattern: "items[1].name", path: []string{"items", "[1]", "name"}, want: matchExact},
+		{name: "Other index", pattern: "items[1]", path: []string{"items", "[2]"}, want: 0},
+		{name: "Quoted key", pattern: `labels["a.b"]`, path: []string{"labels", "a.b"}, want: matchExact},
+		{name: "Identity", pattern: "items[name=web]", path: []string{"items", "[name=web,port=80]"}, want: matchExact},
+		{
+			name:    "Field of element",
+			pattern: "items[name=web].image",
+			path:    []string{"items", "[0]", "image"},
+			nodes:   [][2]*StructuredData{{}, {nil, web}},
+			want:    matchExact,
+		},
+		{
+			name:    "Field without values",
+			pattern: "items[name=web].image",
+			path:    []string{"items", "[0]", "image"},
+			want:    0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MustParsePathPattern(tt.pattern).match(tt.path, tt.nodes); got != tt.want {
+				t.Errorf("match() = %b, want %b", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadPathPatterns(t *testing.T) {
+	patterns, err := ReadPathPatterns(strings.NewReader("# volatile fields\nmetadata.resourceVersion\n\n  status  \n"))
+	if err != nil {
+		t.Fatalf("ReadPathPatterns() error = %v", err)
+	}
+
+	var got []string
+	for _, pattern := range patterns {
+		got = append(got, pattern.String())
+	}
+	if strings.Join(got, " ") != "metadata.resourceVersion status" {
+		t.Errorf("ReadPathPatterns() = %v", got)
+	}
+
+	if _, err := ReadPathPatterns(strings.NewReader("ok\nbad[\n")); err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("ReadPathPatterns() error = %v, want error on line 2", err)
+	}
+}
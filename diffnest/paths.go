@@ -0,0 +1,154 @@
+package diffnest
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Path styles of PathsFormatter.
+const (
+	PathStyleDot      = "dot"      // spec.containers[0].image
+	PathStyleJSONPath = "jsonpath" // $.spec.containers[0].image
+)
+
+// PathsFormatter prints one line per changed value with its status symbol, path,
+// old value and new value, for grep and awk:
+//
+//	~ spec.replicas: 2 -> 3
+//	+ spec.image: nginx
+//	- labels: {2 fields}
+//	> items[2] -> items[0]: web
+//	# spec.replicas: 3 (comment changed)
+//
+// Added or deleted objects and arrays are printed as one line with their size.
+type PathsFormatter struct {
+	ShowOnlyDiff bool   // Without it, unchanged values are printed with "="
+	PathStyle    string // PathStyleDot (default) or PathStyleJSONPath
+}
+
+// Format formats diff results as a list of paths.
+func (f *PathsFormatter) Format(w io.Writer, results []*DiffResult) error {
+	var err error
+
+	for _, result := range results {
+		walkValues(result, func(value *DiffResult) {
+			if err != nil || (f.ShowOnlyDiff && value.Status == StatusSame) {
+				return
+			}
+			if _, writeErr := fmt.Fprintln(w, f.line(value)); writeErr != nil {
+				err = fmt.Errorf("write path: %w", writeErr)
+			}
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (f *PathsFormatter) line(value *DiffResult) string {
+	path := f.path(value.Path)
+
+	switch value.Status {
+	case StatusModified:
+		return fmt.Sprintf("~ %s: %s -> %s", path, pathsValue(value.From), pathsValue(value.To))
+	case StatusAdded:
+		return fmt.Sprintf("+ %s: %s", path, pathsValue(value.To))
+	case StatusDeleted:
+		return fmt.Sprintf("- %s: %s", path, pathsValue(value.From))
+	case StatusMoved, StatusRenamed:
+		return fmt.Sprintf("> %s -> %s: %s", f.path(value.FromPath), path, pathsValue(value.To))
+	case StatusCommentChanged:
+		return fmt.Sprintf("# %s: %s (comment changed)", path, pathsValue(value.To))
+	case StatusTypeChanged:
+		return fmt.Sprintf("= %s: %s (%s)", path, pathsValue(value.To), typeChange(value))
+	case StatusSame:
+	}
+
+	return fmt.Sprintf("= %s: %s", path, pathsValue(value.To))
+}
+
+// path returns path in the configured style. Keys that are not plain words are quoted.
+func (f *PathsFormatter) path(path []string) string {
+	var b strings.Builder
+	if f.PathStyle == PathStyleJSONPath {
+		b.WriteString("$")
+	}
+
+	for _, segment := range path {
+		switch {
+		case strings.HasPrefix(segment, "[") && strings.HasSuffix(segment, "]"):
+			if f.PathStyle == PathStyleJSONPath && strings.Contains(segment, "=") {
+				b.WriteString(jsonPathFilter(segment[1 : len(segment)-1]))
+			} else {
+				b.WriteString(segment)
+			}
+		case isPlainKey(segment):
+			if b.Len() > 0 {
+				b.WriteByte('.')
+			}
+			b.WriteString(segment)
+		case f.PathStyle == PathStyleJSONPath:
+			b.WriteString("[" + jsonPathQuote(segment) + "]")
+		default:
+			b.WriteString("[" + strconv.Quote(segment) + "]")
+		}
+	}
+
+	if b.Len() == 0 {
+		return "."
+	}
+
+	return b.String()
+}
+
+// jsonPathFilter turns an array element identity like "name=app" from the key
+// array strategy into a JSONPath filter expression.
+func jsonPathFilter(identity string) string {
+	var conditions []string
+	for _, part := range strings.Split(identity, ",") {
+		key, value, _ := strings.Cut(part, "=")
+		conditions = append(conditions, "@."+key+"=="+jsonPathQuote(value))
+	}
+
+	return "[?(" + strings.Join(conditions, " && ") + ")]"
+}
+
+// jsonPathQuote returns s as a single-quoted JSONPath string.
+func jsonPathQuote(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(s) + "'"
+}
+
+// isPlainKey reports whether key can be written after a dot without quoting.
+func isPlainKey(key string) bool {
+	if key == "" {
+		return false
+	}
+
+	for _, r := range key {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' && r != '-' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// pathsValue formats data on a single line. Strings with line breaks or other
+// control characters are quoted.
+func pathsValue(data *StructuredData) string {
+	value := (&UnifiedFormatter{}).formatValue(data)
+	if data == nil || data.Type != TypeString || strings.HasPrefix(value, `"`) {
+		return value
+	}
+
+	if strings.IndexFunc(value, unicode.IsControl) >= 0 {
+		return strconv.Quote(value)
+	}
+
+	return value
+}
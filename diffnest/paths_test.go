@@ -0,0 +1,100 @@
+package diffnest
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPathsFormatter_Format(t *testing.T) {
+	const from = `{"name": "app", "spec": {"replicas": 1, "containers": [{"name": "web", "image": "nginx:1"}]},
+		"script": "a\nb", "a.b": {"x y": 1}, "old": {"x": 1, "y": 2}, "items": ["a", "b", "c"]}`
+	const to = `{"name": "app", "spec": {"replicas": 3, "containers": [{"name": "web", "image": "nginx:2"}]},
+		"script": "a\nc", "a.b": {"x y": 2}, "new": [1, 2], "items": ["c", "a", "b"]}`
+
+	tests := []struct {
+		name      string
+		opts      DiffOptions
+		formatter *PathsFormatter
+		want      []string
+	}{
+		{
+			name:      "Dot style",
+			opts:      DiffOptions{ArrayDiffStrategy: ArrayStrategyIndex},
+			formatter: &PathsFormatter{ShowOnlyDiff: true},
+			want: []string{
+				"~ spec.replicas: 1 -> 3",
+				`~ spec.containers[0].image: "nginx:1" -> "nginx:2"`,
+				`~ script: "a\nb" -> "a\nc"`,
+				`~ ["a.b"]["x y"]: 1 -> 2`,
+				"+ new: [2 items]",
+				"- old: {2 fields}",
+				"~ items[0]: a -> c",
+				"~ items[1]: b -> a",
+				"~ items[2]: c -> b",
+			},
+		},
+		{
+			name:      "JSONPath style with moves",
+			opts:      DiffOptions{ArrayDiffStrategy: ArrayStrategyOrdered, DetectMoves: true},
+			formatter: &PathsFormatter{ShowOnlyDiff: true, PathStyle: PathStyleJSONPath},
+			want: []string{
+				"~ $.spec.replicas: 1 -> 3",
+				`~ $.spec.containers[0].image: "nginx:1" -> "nginx:2"`,
+				`~ $.script: "a\nb" -> "a\nc"`,
+				`~ $['a.b']['x y']: 1 -> 2`,
+				"+ $.new: [2 items]",
+				"- $.old: {2 fields}",
+				"> $.items[2] -> $.items[0]: c",
+			},
+		},
+		{
+			name: "JSONPath style with identity keys",
+			opts: DiffOptions{
+				ArrayDiffStrategy: ArrayStrategyKey,
+				ArrayKeys:         []ArrayKeyRule{{Path: "containers", Keys: []string{"name"}}},
+			},
+			formatter: &PathsFormatter{ShowOnlyDiff: true, PathStyle: PathStyleJSONPath},
+			want: []string{
+				"~ $.spec.replicas: 1 -> 3",
+				`~ $.spec.containers[?(@.name=='web')].image: "nginx:1" -> "nginx:2"`,
+				`~ $.script: "a\nb" -> "a\nc"`,
+				`~ $['a.b']['x y']: 1 -> 2`,
+				"+ $.new: [2 items]",
+				"- $.old: {2 fields}",
+			},
+		},
+		{
+			name:      "Show unchanged values",
+			opts:      DiffOptions{},
+			formatter: &PathsFormatter{},
+			want: []string{
+				"= name: app",
+				"~ spec.replicas: 1 -> 3",
+				"= spec.containers[0].name: web",
+				`~ spec.containers[0].image: "nginx:1" -> "nginx:2"`,
+				`~ script: "a\nb" -> "a\nc"`,
+				`~ ["a.b"]["x y"]: 1 -> 2`,
+				"+ new: [2 items]",
+				"- old: {2 fields}",
+				"~ items[0]: a -> c",
+				"~ items[1]: b -> a",
+				"~ items[2]: c -> b",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := NewDiffEngine(tt.opts).Compare(parseJSONDocument(t, from), parseJSONDocument(t, to))
+
+			var buf strings.Builder
+			if err := tt.formatter.Format(&buf, []*DiffResult{result}); err != nil {
+				t.Fatalf("PathsFormatter.Format() error = %v", err)
+			}
+
+			if got, want := buf.String(), strings.Join(tt.want, "\n")+"\n"; got != want {
+				t.Errorf("PathsFormatter.Format() =\n%s\nwant:\n%s", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,319 @@
+package diffnest
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// defaultSideBySideWidth is the output width when neither a width nor a terminal width is known.
+const defaultSideBySideWidth = 120
+
+// Gutter markers of side-by-side rows.
+const (
+	sideSame        = ' '
+	sideModified    = '|'
+	sideDeleted     = '<'
+	sideAdded       = '>'
+	sideMoved       = '~'
+	sideTypeChanged = '='
+	sideComment     = '#'
+)
+
+// SideBySideFormatter renders the first and second documents in two aligned columns
+// with a change marker in the gutter between them.
+type SideBySideFormatter struct {
+	ShowOnlyDiff bool
+	ContextLines int
+	Width        int  // Total output width; defaultSideBySideWidth when not positive
+	SortKeys     bool // Print keys of added/deleted objects alphabetically instead of by source order
+}
+
+// sideBySideRow is one output line with the text of both columns.
+type sideBySideRow struct {
+	left   string
+	right  string
+	marker rune
+	parent int // Index of the row of the enclosing key, -1 at the top level
+}
+
+// Format formats diff results in two columns.
+func (f *SideBySideFormatter) Format(w io.Writer, results []*DiffResult) error {
+	width := f.Width
+	if width <= 0 {
+		width = defaultSideBySideWidth
+	}
+	columnWidth := max((width-3)/2, 1)
+
+	needsSeparator := false
+	for _, result := range results {
+		var rows []sideBySideRow
+		f.addDiff(&rows, result, "", -1)
+
+		rows = f.visibleRows(rows)
+		if len(rows) == 0 {
+			continue
+		}
+
+		if needsSeparator {
+			if _, err := fmt.Fprint(w, "---\n"); err != nil {
+				return fmt.Errorf("write separator: %w", err)
+			}
+		}
+		needsSeparator = true
+
+		for _, row := range rows {
+			line := fitColumn(row.left, columnWidth) + " " + string(row.marker) + " " + fitColumn(row.right, columnWidth)
+			if _, err := fmt.Fprintln(w, strings.TrimRight(line, " ")); err != nil {
+				return fmt.Errorf("write row: %w", err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// addDiff appends the rows of diff, indented by indent, to rows.
+func (f *SideBySideFormatter) addDiff(rows *[]sideBySideRow, diff *DiffResult, indent string, parent int) {
+	switch diff.Status {
+	case StatusDeleted:
+		for _, line := range f.structureLines(diff.From, indent, diff.Path) {
+			*rows = append(*rows, sideBySideRow{left: line, marker: sideDeleted, parent: parent})
+		}
+
+		return
+
+	case StatusAdded:
+		for _, line := range f.structureLines(diff.To, indent, diff.Path) {
+			*rows = append(*rows, sideBySideRow{right: line, marker: sideAdded, parent: parent})
+		}
+
+		return
+
+	case StatusMoved, StatusRenamed:
+		values := &UnifiedFormatter{}
+		*rows = append(*rows, sideBySideRow{
+			left:   indent + valueLabel(diff.FromPath) + values.formatValue(diff.From),
+			right:  indent + valueLabel(diff.Path) + values.formatValue(diff.To),
+			marker: sideMoved,
+			parent: parent,
+		})
+		f.addChildren(rows, diff.Children, indent+"  ", len(*rows)-1)
+
+		return
+
+	case StatusSame, StatusCommentChanged, StatusModified, StatusTypeChanged:
+	}
+
+	if len(diff.Children) == 0 {
+		if diff.Status == StatusModified || diff.Status == StatusTypeChanged {
+			marker := sideModified
+			if diff.Status == StatusTypeChanged {
+				marker = sideTypeChanged
+			}
+			values := &UnifiedFormatter{}
+			*rows = append(*rows, sideBySideRow{
+				left:   indent + valueLabel(diff.Path) + values.formatValue(diff.From),
+				right:  indent + valueLabel(diff.Path) + values.formatValue(diff.To),
+				marker: marker,
+				parent: parent,
+			})
+
+			return
+		}
+
+		for _, line := range f.structureLines(diff.From, indent, diff.Path) {
+			*rows = append(*rows, sideBySideRow{left: line, right: line, marker: commentMarker(diff), parent: parent})
+		}
+
+		return
+	}
+
+	childIndent := indent
+	if len(diff.Path) > 0 {
+		header := indent + headerLabel(diff.Path)
+		if isType(diff.From, TypeString) && isType(diff.To, TypeString) {
+			// Lines of a multiline string
+			header = indent + valueLabel(diff.Path) + "|"
+		}
+		*rows = append(*rows, sideBySideRow{left: header, right: header, marker: commentMarker(diff), parent: parent})
+		parent = len(*rows) - 1
+		childIndent += "  "
+	}
+
+	if isType(diff.From, TypeString) && isType(diff.To, TypeString) {
+		f.addLines(rows, diff.Children, childIndent, parent)
+
+		return
+	}
+
+	f.addChildren(rows, diff.Children, childIndent, parent)
+}
+
+// commentMarker returns the marker of the unchanged rows of diff: sideComment
+// when its comments differ, sideSame otherwise.
+func commentMarker(diff *DiffResult) rune {
+	if diff.Meta != nil && diff.Meta.CommentChanged {
+		return sideComment
+	}
+
+	return sideSame
+}
+
+func (f *SideBySideFormatter) addChildren(rows *[]sideBySideRow, children []*DiffResult, indent string, parent int) {
+	for _, child := range children {
+		f.addDiff(rows, child, indent, parent)
+	}
+}
+
+// addLines appends the rows of the line diff of a multiline string.
+func (f *SideBySideFormatter) addLines(rows *[]sideBySideRow, lines []*DiffResult, indent string, parent int) {
+	for _, line := range lines {
+		row := sideBySideRow{marker: sideSame, parent: parent}
+		if line.From != nil {
+			row.left = indent + fmt.Sprint(line.From.Value)
+		}
+		if line.To != nil {
+			row.right = indent + fmt.Sprint(line.To.Value)
+		}
+
+		switch line.Status {
+		case StatusSame:
+			row.right = row.left
+		case StatusDeleted:
+			row.marker = sideDeleted
+		case StatusAdded:
+			row.marker = sideAdded
+		default:
+			row.marker = sideModified
+		}
+		*rows = append(*rows, row)
+	}
+}
+
+// structureLines renders data as YAML-like lines under the key of path.
+func (f *SideBySideFormatter) structureLines(data *StructuredData, indent string, path []string) []string {
+	values := &UnifiedFormatter{SortKeys: f.SortKeys}
+	if data == nil {
+		return nil
+	}
+
+	isContainer := (data.Type == TypeObject && len(data.Children) > 0) ||
+		(data.Type == TypeArray && len(data.Elements) > 0)
+	if !isContainer {
+		return []string{indent + valueLabel(path) + values.formatValue(data)}
+	}
+
+	var lines []string
+	childIndent := indent
+	if len(path) > 0 {
+		lines = append(lines, indent+headerLabel(path))
+		childIndent += "  "
+	}
+
+	if data.Type == TypeObject {
+		for _, key := range values.objectKeys(data) {
+			lines = append(lines, f.structureLines(data.Children[key], childIndent, []string{key})...)
+		}
+	} else {
+		for i, elem := range data.Elements {
+			lines = append(lines, f.structureLines(elem, childIndent, []string{fmt.Sprintf("[%d]", i)})...)
+		}
+	}
+
+	return lines
+}
+
+// visibleRows applies ShowOnlyDiff and ContextLines to rows. Changes are shown with
+// the surrounding context rows and the keys enclosing them; "..." marks skipped rows.
+func (f *SideBySideFormatter) visibleRows(rows []sideBySideRow) []sideBySideRow {
+	if !f.ShowOnlyDiff {
+		return rows
+	}
+
+	contextLines := max(f.ContextLines, 0)
+	keep := make([]bool, len(rows))
+	for i, row := range rows {
+		if row.marker == sideSame {
+			continue
+		}
+		for j := max(i-contextLines, 0); j <= min(i+contextLines, len(rows)-1); j++ {
+			keep[j] = true
+		}
+	}
+
+	for i := range rows {
+		if keep[i] {
+			for p := rows[i].parent; p >= 0 && !keep[p]; p = rows[p].parent {
+				keep[p] = true
+			}
+		}
+	}
+
+	var visible []sideBySideRow
+	last := -1
+	for i, row := range rows {
+		if !keep[i] {
+			continue
+		}
+		if last >= 0 && i > last+1 && f.ContextLines >= 0 {
+			text := rowIndent(row) + "..."
+			visible = append(visible, sideBySideRow{left: text, right: text, marker: sideSame})
+		}
+		visible = append(visible, row)
+		last = i
+	}
+
+	return visible
+}
+
+// rowIndent returns the leading spaces of the text in row.
+func rowIndent(row sideBySideRow) string {
+	text := row.left
+	if text == "" {
+		text = row.right
+	}
+
+	return text[:len(text)-len(strings.TrimLeft(text, " "))]
+}
+
+// valueLabel returns the prefix of a value line for the last segment of path.
+func valueLabel(path []string) string {
+	if len(path) == 0 {
+		return ""
+	}
+
+	key := path[len(path)-1]
+	if strings.HasPrefix(key, "[") && strings.HasSuffix(key, "]") {
+		return "- "
+	}
+
+	return key + ": "
+}
+
+// headerLabel returns the line opening a nested object or array under the last segment of path.
+func headerLabel(path []string) string {
+	return strings.TrimSuffix(valueLabel(path), " ")
+}
+
+// fitColumn pads or truncates text to width terminal columns. Line breaks and tabs are escaped to keep rows aligned.
+func fitColumn(text string, width int) string {
+	text = strings.NewReplacer("\n", `\n`, "\t", `\t`).Replace(text)
+
+	length := displayWidth(text)
+	if length > width {
+		var b strings.Builder
+		used := 0
+		for _, r := range text {
+			if used+runeWidth(r) > width-1 {
+				break
+			}
+			b.WriteRune(r)
+			used += runeWidth(r)
+		}
+
+		return b.String() + "…" + strings.Repeat(" ", width-1-used)
+	}
+
+	return text + strings.Repeat(" ", width-length)
+}
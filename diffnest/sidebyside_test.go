@@ -0,0 +1,152 @@
+package diffnest
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSideBySideFormatter_Format(t *testing.T) {
+	const from = `{"name": "app", "version": 1, "spec": {"a": 1, "b": 2, "c": 3, "d": 4}, "old": {"x": 1}}`
+	const to = `{"name": "app", "version": 2, "spec": {"a": 1, "b": 2, "c": 3, "d": 5}, "tags": ["web"]}`
+
+	tests := []struct {
+		name      string
+		formatter *SideBySideFormatter
+		want      []string
+	}{
+		{
+			name:      "Show all",
+			formatter: &SideBySideFormatter{Width: 43},
+			want: []string{
+				"name: app              name: app",
+				"version: 1           | version: 2",
+				"spec:                  spec:",
+				"  a: 1                   a: 1",
+				"  b: 2                   b: 2",
+				"  c: 3                   c: 3",
+				"  d: 4               |   d: 5",
+				"                     > tags:",
+				"                     >   - web",
+				"old:                 <",
+				"  x: 1               <",
+			},
+		},
+		{
+			name:      "Context lines",
+			formatter: &SideBySideFormatter{Width: 43, ShowOnlyDiff: true, ContextLines: 1},
+			want: []string{
+				"name: app              name: app",
+				"version: 1           | version: 2",
+				"spec:                  spec:",
+				"  ...                    ...",
+				"  c: 3                   c: 3",
+				"  d: 4               |   d: 5",
+				"                     > tags:",
+				"                     >   - web",
+				"old:                 <",
+				"  x: 1               <",
+			},
+		},
+		{
+			name:      "Only changes",
+			formatter: &SideBySideFormatter{Width: 43, ShowOnlyDiff: true, ContextLines: -1},
+			want: []string{
+				"version: 1           | version: 2",
+				"spec:                  spec:",
+				"  d: 4               |   d: 5",
+				"                     > tags:",
+				"                     >   - web",
+				"old:                 <",
+				"  x: 1               <",
+			},
+		},
+		{
+			name:      "Truncate to column width",
+			formatter: &SideBySideFormatter{Width: 15, ShowOnlyDiff: true, ContextLines: -1},
+			want: []string{
+				"versi… | versi…",
+				"spec:    spec:",
+				"  d: 4 |   d: 5",
+				"       > tags:",
+				"       >   - w…",
+				"old:   <",
+				"  x: 1 <",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := NewDiffEngine(DiffOptions{}).Compare(parseJSONDocument(t, from), parseJSONDocument(t, to))
+
+			var buf strings.Builder
+			if err := tt.formatter.Format(&buf, []*DiffResult{result}); err != nil {
+				t.Fatalf("SideBySideFormatter.Format() error = %v", err)
+			}
+
+			if got, want := buf.String(), strings.Join(tt.want, "\n")+"\n"; got != want {
+				t.Errorf("SideBySideFormatter.Format() =\n%s\nwant:\n%s", got, want)
+			}
+		})
+	}
+}
+
+func TestSideBySideFormatter_MultilineString(t *testing.T) {
+	result := NewDiffEngine(DiffOptions{}).Compare(
+		parseJSONDocument(t, `{"script": "echo a\necho b\necho c"}`),
+		parseJSONDocument(t, `{"script": "echo a\necho B\necho c\necho d"}`),
+	)
+
+	formatter := &SideBySideFormatter{Width: 43}
+	var buf strings.Builder
+	if err := formatter.Format(&buf, []*DiffResult{result}); err != nil {
+		t.Fatalf("SideBySideFormatter.Format() error = %v", err)
+	}
+
+	want := "script: |              script: |\n" +
+		"  echo a                 echo a\n" +
+		"  echo b             |   echo B\n" +
+		"  echo c                 echo c\n" +
+		"                     >   echo d\n"
+	if got := buf.String(); got != want {
+		t.Errorf("SideBySideFormatter.Format() =\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestSideBySideFormatter_CommentChanges(t *testing.T) {
+	result := NewDiffEngine(DiffOptions{CompareComments: true}).Compare(
+		parseYAMLDocument(t, "name: app\nport: 80 # web\nspec:\n  a: 1\n"),
+		parseYAMLDocument(t, "name: app\nport: 80 # http\n# the spec\nspec:\n  a: 1\n"),
+	)
+
+	formatter := &SideBySideFormatter{Width: 31, ShowOnlyDiff: true, ContextLines: -1}
+	var buf strings.Builder
+	if err := formatter.Format(&buf, []*DiffResult{result}); err != nil {
+		t.Fatalf("SideBySideFormatter.Format() error = %v", err)
+	}
+
+	want := "port: 80       # port: 80\n" +
+		"spec:          # spec:\n"
+	if got := buf.String(); got != want {
+		t.Errorf("SideBySideFormatter.Format() =\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestSideBySideFormatter_WideCharacters(t *testing.T) {
+	result := NewDiffEngine(DiffOptions{}).Compare(
+		parseJSONDocument(t, `{"名前": "アプリ", "note": "日本語のテキスト"}`),
+		parseJSONDocument(t, `{"名前": "app", "note": "日本語のテキスト"}`),
+	)
+
+	formatter := &SideBySideFormatter{Width: 31}
+	var buf strings.Builder
+	if err := formatter.Format(&buf, []*DiffResult{result}); err != nil {
+		t.Fatalf("SideBySideFormatter.Format() error = %v", err)
+	}
+
+	want := "名前: アプリ   | 名前: app\n" +
+		"note: 日本語…    note: 日本語…\n"
+	if got := buf.String(); got != want {
+		t.Errorf("SideBySideFormatter.Format() =\n%s\nwant:\n%s", got, want)
+	}
+}
@@ -0,0 +1,183 @@
+package diffnest
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// defaultStatTop is the default number of most changed subtrees in summaries.
+const defaultStatTop = 5
+
+// StatFormatter summarizes how much changed and where: the document pairing,
+// the number of changed values by status, the total DiffCount and the most
+// changed subtrees. With Next set, the summary is a header before Next's output.
+type StatFormatter struct {
+	Top  int       // Number of most changed subtrees to list
+	Next Formatter // Formatter writing the diff after the summary
+}
+
+// statSubtree is an object or array containing changes.
+type statSubtree struct {
+	document int // Index in the results
+	path     []string
+	count    int
+}
+
+// Format writes the summary, followed by the output of Next.
+func (f *StatFormatter) Format(w io.Writer, results []*DiffResult) error {
+	var b strings.Builder
+	f.writeStat(&b, results)
+
+	if _, err := io.WriteString(w, b.String()); err != nil {
+		return fmt.Errorf("write stat: %w", err)
+	}
+
+	if f.Next == nil || !HasDifferences(results) {
+		return nil
+	}
+
+	if _, err := io.WriteString(w, "\n"); err != nil {
+		return fmt.Errorf("write stat: %w", err)
+	}
+
+	return f.Next.Format(w, results)
+}
+
+func (f *StatFormatter) writeStat(b *strings.Builder, results []*DiffResult) {
+	counts := make(map[DiffStatus]int)
+	diffTotal := 0
+
+	pairs := make([]string, len(results))
+	width := 0
+	for i, result := range results {
+		pairs[i] = documentPairLabel(i, result)
+		width = max(width, len(pairs[i]))
+	}
+
+	b.WriteString("Documents:\n")
+	for i, result := range results {
+		documentCounts := make(map[DiffStatus]int)
+		countStatuses(result, documentCounts)
+		for status, count := range documentCounts {
+			counts[status] += count
+		}
+		diffTotal += diffCount(result)
+
+		status := result.Status
+		if status == StatusSame && hasTypeChanges(result) {
+			status = StatusTypeChanged
+		}
+		fmt.Fprintf(b, "  %-*s  %s", width, pairs[i], status)
+		if status == StatusModified {
+			fmt.Fprintf(b, " (%s)", pluralize(changeCount(documentCounts), "change"))
+		}
+		b.WriteString("\n")
+	}
+
+	var parts []string
+	for _, status := range summaryStatuses {
+		if status != StatusSame && counts[status] > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", counts[status], strings.ToLower(statusLabel(status))))
+		}
+	}
+	if len(parts) == 0 {
+		parts = append(parts, "none")
+	}
+	fmt.Fprintf(b, "Changes: %s\n", strings.Join(parts, ", "))
+	fmt.Fprintf(b, "Diff count: %d\n", diffTotal)
+
+	subtrees := mostChangedSubtrees(results, f.Top)
+	if len(subtrees) == 0 {
+		return
+	}
+
+	b.WriteString("Most changed:\n")
+	countWidth := len(fmt.Sprint(subtrees[0].count))
+	for _, subtree := range subtrees {
+		path := displayPath(subtree.path)
+		if len(results) > 1 {
+			path = pairs[subtree.document] + " " + path
+		}
+		fmt.Fprintf(b, "  %*d  %s\n", countWidth, subtree.count, path)
+	}
+}
+
+// documentPairLabel returns the 1-based numbers of the documents compared in result,
+// like "#1 -> #2", with "-" for a missing document.
+func documentPairLabel(index int, result *DiffResult) string {
+	from, to := index, index
+	if result.Meta != nil && result.Meta.Document != nil {
+		from, to = result.Meta.Document.From, result.Meta.Document.To
+	}
+
+	label := func(i int) string {
+		if i < 0 {
+			return "-"
+		}
+
+		return fmt.Sprintf("#%d", i+1)
+	}
+
+	return label(from) + " -> " + label(to)
+}
+
+// mostChangedSubtrees returns up to n objects and arrays with the highest DiffCount.
+// On equal counts deeper subtrees come first, and a subtree is left out when one of
+// its descendants already listed holds all of its changes.
+func mostChangedSubtrees(results []*DiffResult, n int) []statSubtree {
+	if n <= 0 {
+		return nil
+	}
+
+	var candidates []statSubtree
+	var collect func(document int, diff *DiffResult)
+	collect = func(document int, diff *DiffResult) {
+		if len(diff.Children) == 0 || isType(diff.From, TypeString) {
+			return
+		}
+		if count := diffCount(diff); len(diff.Path) > 0 && count > 0 {
+			candidates = append(candidates, statSubtree{document: document, path: diff.Path, count: count})
+		}
+		for _, child := range diff.Children {
+			collect(document, child)
+		}
+	}
+	for i, result := range results {
+		collect(i, result)
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].count != candidates[j].count {
+			return candidates[i].count > candidates[j].count
+		}
+
+		return len(candidates[i].path) > len(candidates[j].path)
+	})
+
+	var selected []statSubtree
+	for _, candidate := range candidates {
+		if len(selected) == n {
+			break
+		}
+		if !containsSelected(selected, candidate) {
+			selected = append(selected, candidate)
+		}
+	}
+
+	return selected
+}
+
+// containsSelected reports whether a selected subtree inside candidate has the same count.
+func containsSelected(selected []statSubtree, candidate statSubtree) bool {
+	for _, s := range selected {
+		if s.document == candidate.document && s.count == candidate.count &&
+			len(s.path) > len(candidate.path) && slices.Equal(s.path[:len(candidate.path)], candidate.path) {
+			return true
+		}
+	}
+
+	return false
+}
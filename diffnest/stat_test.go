@@ -0,0 +1,81 @@
+package diffnest
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestStatFormatter_Format(t *testing.T) {
+	docsA, err := ParseWithFormat(strings.NewReader(
+		"kind: Deployment\nspec:\n  replicas: 2\n  containers:\n    - {name: web, image: nginx:1, port: 80}\n"+
+			"---\nkind: Service\nport: 80\n"+
+			"---\nkind: ConfigMap\ndata: {a: 1}\n"), FormatYAML)
+	if err != nil {
+		t.Fatalf("ParseWithFormat() error = %v", err)
+	}
+	docsB, err := ParseWithFormat(strings.NewReader(
+		"kind: Service\nport: 80\n"+
+			"---\nkind: Deployment\nspec:\n  replicas: 3\n  containers:\n    - {name: web, image: nginx:2, port: 8080}\n"), FormatYAML)
+	if err != nil {
+		t.Fatalf("ParseWithFormat() error = %v", err)
+	}
+	results := Compare(docsA, docsB, DiffOptions{ArrayDiffStrategy: ArrayStrategyIndex})
+
+	tests := []struct {
+		name      string
+		formatter *StatFormatter
+		results   []*DiffResult
+		want      string
+	}{
+		{
+			name:      "Multiple documents",
+			formatter: &StatFormatter{Top: 3},
+			results:   results,
+			want: "Documents:\n" +
+				"  #1 -> #2  modified (3 changes)\n" +
+				"  #2 -> #1  same\n" +
+				"  #3 -> -   deleted\n" +
+				"Changes: 3 modified, 1 deleted\n" +
+				"Diff count: 5\n" +
+				"Most changed:\n" +
+				"  3  #1 -> #2 spec\n" +
+				"  2  #1 -> #2 spec.containers[0]\n",
+		},
+		{
+			name:      "No subtrees",
+			formatter: &StatFormatter{},
+			results:   results[1:2],
+			want: "Documents:\n" +
+				"  #2 -> #1  same\n" +
+				"Changes: none\n" +
+				"Diff count: 0\n",
+		},
+		{
+			name:      "Header before diff",
+			formatter: &StatFormatter{Top: 1, Next: &PathsFormatter{ShowOnlyDiff: true}},
+			results:   results[:1],
+			want: "Documents:\n" +
+				"  #1 -> #2  modified (3 changes)\n" +
+				"Changes: 3 modified\n" +
+				"Diff count: 3\n" +
+				"Most changed:\n" +
+				"  3  spec\n" +
+				"\n" +
+				"~ spec.replicas: 2 -> 3\n" +
+				`~ spec.containers[0].image: "nginx:1" -> "nginx:2"` + "\n" +
+				"~ spec.containers[0].port: 80 -> 8080\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf strings.Builder
+			if err := tt.formatter.Format(&buf, tt.results); err != nil {
+				t.Fatalf("StatFormatter.Format() error = %v", err)
+			}
+			if got := buf.String(); got != tt.want {
+				t.Errorf("StatFormatter.Format() =\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
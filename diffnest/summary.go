@@ -0,0 +1,95 @@
+package diffnest
+
+import "strings"
+
+// summaryStatuses is the order of statuses in diff summaries.
+//
+//nolint:gochecknoglobals
+var summaryStatuses = []DiffStatus{
+	StatusModified,
+	StatusAdded,
+	StatusDeleted,
+	StatusMoved,
+	StatusRenamed,
+	StatusCommentChanged,
+	StatusTypeChanged,
+	StatusSame,
+}
+
+// statusLabel returns the human readable name of status.
+func statusLabel(status DiffStatus) string {
+	switch status {
+	case StatusSame:
+		return "Unchanged"
+	case StatusCommentChanged:
+		return "Comment changed"
+	case StatusTypeChanged:
+		return "Type changed"
+	default:
+		name := status.String()
+
+		return strings.ToUpper(name[:1]) + name[1:]
+	}
+}
+
+// countStatuses adds the number of changed and unchanged values in diff to counts.
+func countStatuses(diff *DiffResult, counts map[DiffStatus]int) {
+	walkValues(diff, func(value *DiffResult) {
+		counts[value.Status]++
+	})
+}
+
+// changeCount returns the number of changed values in counts. Type-only changes
+// are not counted.
+func changeCount(counts map[DiffStatus]int) int {
+	total := 0
+	for status, count := range counts {
+		if status != StatusSame && status != StatusTypeChanged {
+			total += count
+		}
+	}
+
+	return total
+}
+
+// walkValues calls fn for each compared value in diff. Objects and arrays containing
+// changes are not values themselves; added or deleted subtrees and multiline strings
+// are single values. Moved and renamed values are visited before their inner changes.
+func walkValues(diff *DiffResult, fn func(*DiffResult)) {
+	switch diff.Status {
+	case StatusSame, StatusModified, StatusTypeChanged:
+		if len(diff.Children) == 0 || (isType(diff.From, TypeString) && isType(diff.To, TypeString)) {
+			fn(diff)
+
+			return
+		}
+	case StatusMoved, StatusRenamed:
+		fn(diff)
+	case StatusAdded, StatusDeleted, StatusCommentChanged:
+		fn(diff)
+
+		return
+	}
+
+	for _, child := range diff.Children {
+		walkValues(child, fn)
+	}
+}
+
+// displayPath joins path with dots, attaching array indices to the preceding key
+// as in "spec.ports[0]". The root is ".".
+func displayPath(path []string) string {
+	if len(path) == 0 {
+		return "."
+	}
+
+	var b strings.Builder
+	for i, segment := range path {
+		if i > 0 && !strings.HasPrefix(segment, "[") {
+			b.WriteByte('.')
+		}
+		b.WriteString(segment)
+	}
+
+	return b.String()
+}
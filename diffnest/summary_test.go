@@ -0,0 +1,39 @@
+package diffnest
+
+import "testing"
+
+func TestCountStatuses(t *testing.T) {
+	engine := NewDiffEngine(DiffOptions{})
+	result := engine.Compare(
+		parseJSONDocument(t, `{"a": 1, "b": {"c": 2, "d": [1, 2]}, "s": "x\ny", "gone": {"x": 1, "y": 2}}`),
+		parseJSONDocument(t, `{"a": 1, "b": {"c": 3, "d": [1, 2]}, "s": "x\nz", "new": 1}`),
+	)
+
+	counts := make(map[DiffStatus]int)
+	countStatuses(result, counts)
+
+	want := map[DiffStatus]int{StatusSame: 3, StatusModified: 2, StatusAdded: 1, StatusDeleted: 1}
+	for _, status := range summaryStatuses {
+		if counts[status] != want[status] {
+			t.Errorf("countStatuses()[%s] = %d, want %d", status, counts[status], want[status])
+		}
+	}
+}
+
+func TestDisplayPath(t *testing.T) {
+	tests := []struct {
+		path []string
+		want string
+	}{
+		{path: nil, want: "."},
+		{path: []string{"name"}, want: "name"},
+		{path: []string{"spec", "ports", "[0]", "port"}, want: "spec.ports[0].port"},
+		{path: []string{"[1]", "[2]"}, want: "[1][2]"},
+	}
+
+	for _, tt := range tests {
+		if got := displayPath(tt.path); got != tt.want {
+			t.Errorf("displayPath(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
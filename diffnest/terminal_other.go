@@ -0,0 +1,15 @@
+//go:build !linux && !darwin
+
+package diffnest
+
+import "os"
+
+// terminalColumns treats character devices as terminals, whose width is not known on this platform.
+func terminalColumns(file *os.File) (columns int, ok bool) {
+	info, err := file.Stat()
+	if err != nil {
+		return 0, false
+	}
+
+	return 0, info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,25 @@
+//go:build linux || darwin
+
+package diffnest
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// terminalColumns returns the width of the terminal attached to file.
+// ok is false when file is not a terminal.
+func terminalColumns(file *os.File) (columns int, ok bool) {
+	var size struct {
+		rows, cols, xPixel, yPixel uint16
+	}
+
+	//nolint:gosec // TIOCGWINSZ fills the winsize struct passed by pointer
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, file.Fd(), uintptr(syscall.TIOCGWINSZ), uintptr(unsafe.Pointer(&size)))
+	if errno != 0 {
+		return 0, false
+	}
+
+	return int(size.cols), true
+}
@@ -0,0 +1,25 @@
+//go:build linux || darwin
+
+package diffnest
+
+import (
+	"bytes"
+	"os"
+	"testing"
+)
+
+func TestIsTerminal(t *testing.T) {
+	devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatalf("OpenFile() error = %v", err)
+	}
+	defer devNull.Close()
+
+	// /dev/null is a character device but not a terminal
+	if IsTerminal(devNull) {
+		t.Errorf("IsTerminal(%s) = true, want false", os.DevNull)
+	}
+	if IsTerminal(&bytes.Buffer{}) {
+		t.Error("IsTerminal(buffer) = true, want false")
+	}
+}
@@ -0,0 +1,87 @@
+package diffnest
+
+import (
+	"math"
+	"math/big"
+	"strconv"
+)
+
+// Tolerance lets numbers differ a little and still compare equal: by up to
+// Absolute, or by up to Relative times the larger of their magnitudes.
+type Tolerance struct {
+	Absolute float64
+	Relative float64 // e.g. 0.01 for 1%
+}
+
+// PathTolerance is the tolerance for numbers at paths matching Path.
+type PathTolerance struct {
+	Path      PathPattern
+	Tolerance Tolerance
+}
+
+// IsZero reports whether the tolerance requires exact equality.
+func (t Tolerance) IsZero() bool {
+	return t.Absolute == 0 && t.Relative == 0
+}
+
+// within reports whether a and b differ by no more than the tolerance.
+func (t Tolerance) within(a, b float64) bool {
+	diff := math.Abs(a - b)
+
+	return diff <= t.Absolute || diff <= t.Relative*math.Max(math.Abs(a), math.Abs(b))
+}
+
+// toleranceFor returns the tolerance for the number at path: the first matching
+// PathTolerance, or the global Tolerance.
+func (e *DiffEngine) toleranceFor(path []string, a, b *StructuredData) Tolerance {
+	if len(e.options.PathTolerances) > 0 {
+		nodes := e.pathNodes(path, a, b)
+		for _, rule := range e.options.PathTolerances {
+			if rule.Path.match(path, nodes)&(matchExact|matchInside) != 0 {
+				return rule.Tolerance
+			}
+		}
+	}
+
+	return e.options.Tolerance
+}
+
+// numericDelta describes the change of a number, like "+0.0003, +2.1%". The
+// percentage is left out when the old value is zero.
+func numericDelta(from, to *StructuredData) (string, bool) {
+	if from == nil || to == nil || from.Type != TypeNumber || to.Type != TypeNumber {
+		return "", false
+	}
+
+	fromRat, fromOK := numberRat(from.Value)
+	toRat, toOK := numberRat(to.Value)
+	if !fromOK || !toOK {
+		return "", false
+	}
+
+	diff := new(big.Rat).Sub(toRat, fromRat)
+	delta := diff.Num().String()
+	if !diff.IsInt() {
+		f, _ := diff.Float64()
+		delta = strconv.FormatFloat(f, 'g', 6, 64)
+	}
+	if diff.Sign() >= 0 {
+		delta = "+" + delta
+	}
+
+	if fromRat.Sign() == 0 {
+		return delta, true
+	}
+
+	percent, _ := new(big.Rat).Quo(diff, new(big.Rat).Abs(fromRat)).Float64()
+	percent *= 100
+	formatted := strconv.FormatFloat(percent, 'g', 3, 64)
+	if math.Abs(percent) >= 1000 {
+		formatted = strconv.FormatFloat(percent, 'f', 0, 64)
+	}
+	if diff.Sign() >= 0 {
+		formatted = "+" + formatted
+	}
+
+	return delta + ", " + formatted + "%", true
+}
@@ -0,0 +1,81 @@
+package diffnest
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestDiffEngine_Tolerance(t *testing.T) {
+	tests := []struct {
+		name           string
+		from           string
+		to             string
+		tolerance      Tolerance
+		pathTolerances []PathTolerance
+		want           DiffStatus
+	}{
+		{name: "Exact without tolerance", from: `{"a": 0.1}`, to: `{"a": 0.1000000000001}`, want: StatusModified},
+		{name: "Absolute", from: `{"a": 0.1}`, to: `{"a": 0.1000000000001}`, tolerance: Tolerance{Absolute: 1e-9}, want: StatusSame},
+		{name: "Absolute exceeded", from: `{"a": 0.1}`, to: `{"a": 0.2}`, tolerance: Tolerance{Absolute: 1e-9}, want: StatusModified},
+		{name: "Relative", from: `{"a": 1000}`, to: `{"a": 1009}`, tolerance: Tolerance{Relative: 0.01}, want: StatusSame},
+		{name: "Relative exceeded", from: `{"a": 1000}`, to: `{"a": 1011}`, tolerance: Tolerance{Relative: 0.01}, want: StatusModified},
+		{name: "Either tolerance", from: `{"a": 0}`, to: `{"a": 0.0005}`, tolerance: Tolerance{Absolute: 0.001, Relative: 0.01}, want: StatusSame},
+		{
+			name:           "Path tolerance",
+			from:           `{"metrics": {"loss": 0.25}, "a": 1}`,
+			to:             `{"metrics": {"loss": 0.26}, "a": 1}`,
+			pathTolerances: []PathTolerance{{Path: MustParsePathPattern("metrics"), Tolerance: Tolerance{Relative: 0.05}}},
+			want:           StatusSame,
+		},
+		{
+			name:           "Path tolerance overrides global",
+			from:           `{"metrics": {"loss": 0.25}}`,
+			to:             `{"metrics": {"loss": 0.26}}`,
+			tolerance:      Tolerance{Absolute: 0.1},
+			pathTolerances: []PathTolerance{{Path: MustParsePathPattern("**.loss"), Tolerance: Tolerance{Absolute: 0.001}}},
+			want:           StatusModified,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := DiffOptions{Tolerance: tt.tolerance, PathTolerances: tt.pathTolerances}
+			result := NewDiffEngine(opts).Compare(parseJSONDocument(t, tt.from), parseJSONDocument(t, tt.to))
+			if result.Status != tt.want {
+				t.Errorf("Compare() status = %v, want %v", result.Status, tt.want)
+			}
+		})
+	}
+}
+
+func TestNumericDelta(t *testing.T) {
+	tests := []struct {
+		name   string
+		from   any
+		to     any
+		want   string
+		wantOK bool
+	}{
+		{name: "Float", from: 1.0, to: 1.0003, want: "+0.0003, +0.03%", wantOK: true},
+		{name: "Integer", from: 100, to: 98, want: "-2, -2%", wantOK: true},
+		{name: "From zero", from: 0, to: 3, want: "+3", wantOK: true},
+		{name: "Large change", from: 2, to: 500, want: "+498, +24900%", wantOK: true},
+		{name: "Negative base", from: -4.0, to: -3.0, want: "+1, +25%", wantOK: true},
+		{name: "Beyond 64 bits", from: json.Number("123456789012345678901234567890"), to: json.Number("123456789012345678901234567891"), want: "+1, +8.1e-28%", wantOK: true},
+		{name: "Not a number", from: "1", to: 2, wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			from := &StructuredData{Type: TypeNumber, Value: tt.from}
+			if _, ok := tt.from.(string); ok {
+				from.Type = TypeString
+			}
+
+			got, ok := numericDelta(from, &StructuredData{Type: TypeNumber, Value: tt.to})
+			if ok != tt.wantOK || got != tt.want {
+				t.Errorf("numericDelta() = %q, %v, want %q, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
@@ -1,5 +1,12 @@
 package diffnest
 
+import (
+	"fmt"
+	"sort"
+
+	"github.com/goccy/go-yaml"
+)
+
 // DataType represents the type of structured data.
 type DataType int
 
@@ -12,21 +19,74 @@ const (
 	TypeObject
 )
 
+// String returns the name of the type.
+func (t DataType) String() string {
+	switch t {
+	case TypeNull:
+		return "null"
+	case TypeBool:
+		return "bool"
+	case TypeNumber:
+		return "number"
+	case TypeString:
+		return "string"
+	case TypeArray:
+		return "array"
+	case TypeObject:
+		return "object"
+	}
+
+	return fmt.Sprintf("DataType(%d)", int(t))
+}
+
 // StructuredData represents format-agnostic structured data.
 type StructuredData struct {
 	Type     DataType
 	Value    any                        // Actual value for primitives
 	Children map[string]*StructuredData // For objects
+	Keys     []string                   // Order of object keys in the source
 	Elements []*StructuredData          // For arrays
 	Meta     *Metadata                  // Format-specific metadata
 }
 
+// ChildKeys returns the object keys in source order.
+// Keys missing from the recorded order are appended alphabetically.
+func (d *StructuredData) ChildKeys() []string {
+	keys := make([]string, 0, len(d.Children))
+	seen := make(map[string]bool, len(d.Children))
+
+	for _, key := range d.Keys {
+		if _, ok := d.Children[key]; ok && !seen[key] {
+			seen[key] = true
+			keys = append(keys, key)
+		}
+	}
+
+	if len(keys) == len(d.Children) {
+		return keys
+	}
+
+	rest := make([]string, 0, len(d.Children)-len(keys))
+	for key := range d.Children {
+		if !seen[key] {
+			rest = append(rest, key)
+		}
+	}
+	sort.Strings(rest)
+
+	return append(keys, rest...)
+}
+
 // Metadata contains format-specific information.
 type Metadata struct {
 	Format      string      // "json", "yaml", "toml"
-	Location    *Location   // Position in source file
+	Location    *Location   // Position in source file (the key position for object members)
 	Comments    []string    // Comments (YAML/TOML)
 	StringStyle StringStyle // Style of string representation (for YAML)
+	Datetime    bool        // A TOML datetime, held as its string form
+	Literal     string      // A YAML number as written when it is not in JSON syntax, such as 0x1F or 1_000
+
+	yamlComments []*yaml.Comment // Comments with their YAML positions, to write them back
 }
 
 // StringStyle represents YAML string representation style.
@@ -54,12 +114,41 @@ const (
 	StatusModified
 	StatusAdded
 	StatusDeleted
+	StatusCommentChanged // Only the attached comments differ
+	StatusMoved          // Array element moved from FromPath to Path
+	StatusRenamed        // Object key renamed from FromPath to Path
+	StatusTypeChanged    // Equal only after type coercion, like "8080" and 8080; not a difference
 )
 
+// String returns the name of the status.
+func (s DiffStatus) String() string {
+	switch s {
+	case StatusSame:
+		return "same"
+	case StatusModified:
+		return "modified"
+	case StatusAdded:
+		return "added"
+	case StatusDeleted:
+		return "deleted"
+	case StatusCommentChanged:
+		return "comment-changed"
+	case StatusMoved:
+		return "moved"
+	case StatusRenamed:
+		return "renamed"
+	case StatusTypeChanged:
+		return "type-changed"
+	}
+
+	return fmt.Sprintf("DiffStatus(%d)", int(s))
+}
+
 // DiffResult represents the result of comparing two structures.
 type DiffResult struct {
 	Status   DiffStatus
 	Path     []string // Path to this element
+	FromPath []string // Source path for StatusMoved and StatusRenamed
 	From     *StructuredData
 	To       *StructuredData
 	Children []*DiffResult // For nested structures
@@ -68,6 +157,16 @@ type DiffResult struct {
 
 // DiffMeta contains additional diff information.
 type DiffMeta struct {
-	DiffCount int // Size of the difference
-	Note      string
+	DiffCount      int // Size of the difference
+	Note           string
+	CommentChanged bool          // Comments of From and To differ
+	Document       *DocumentPair // Paired documents, set on the results of Compare
+	Excluded       bool          // Left out of the comparison by IgnorePaths or OnlyPaths
+}
+
+// DocumentPair holds the indices of the compared documents in their files.
+// An index is -1 when the document has no counterpart.
+type DocumentPair struct {
+	From int
+	To   int
 }
@@ -1,20 +1,33 @@
 package main
 
 import (
+	"bytes"
 	"errors"
 	"flag"
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
 
 	"github.com/sters/diffnest/diffnest"
 )
 
+// Exit codes follow diff(1).
+const (
+	exitSame      = 0 // No differences
+	exitDifferent = 1 // Differences found
+	exitTrouble   = 2 // Invalid arguments, unreadable or unparsable input, or failed output
+)
+
 func main() {
 	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
 }
 
 func run(args []string, stdout, stderr io.Writer) int {
+	if len(args) > 0 && args[0] == "apply" {
+		return runApply(args[1:], stdout, stderr)
+	}
+
 	cmd := diffnest.NewCommand("diffnest", flag.ContinueOnError)
 	cmd.SetOutput(stderr)
 
@@ -23,7 +36,7 @@ func run(args []string, stdout, stderr io.Writer) int {
 			fmt.Fprintf(stderr, "Error: %v\n", err)
 		}
 
-		return 1
+		return exitTrouble
 	}
 
 	if cmd.Help {
@@ -32,17 +45,29 @@ func run(args []string, stdout, stderr io.Writer) int {
 		return 0
 	}
 
+	cmd.Terminal = diffnest.IsTerminal(stdout)
+	cmd.TerminalWidth = diffnest.TerminalWidth(stdout)
+
 	if cmd.ShowVersion {
 		fmt.Fprintf(stdout, "diffnest version %s\n", diffnest.Version)
 
 		return 0
 	}
 
+	output := stdout
+	if cmd.Quiet {
+		output = io.Discard
+	}
+
+	if isDir(cmd.File1) || isDir(cmd.File2) {
+		return runDirectories(cmd, output, stderr)
+	}
+
 	reader1, err := openFile(cmd.File1)
 	if err != nil {
 		fmt.Fprintf(stderr, "Error opening first file: %v\n", err)
 
-		return 1
+		return exitTrouble
 	}
 	defer closeReader(reader1)
 
@@ -50,7 +75,7 @@ func run(args []string, stdout, stderr io.Writer) int {
 	if err != nil {
 		fmt.Fprintf(stderr, "Error opening second file: %v\n", err)
 
-		return 1
+		return exitTrouble
 	}
 	defer closeReader(reader2)
 
@@ -61,6 +86,41 @@ func run(args []string, stdout, stderr io.Writer) int {
 		cmd.GetFormat2(),
 		cmd.GetDiffOptions(),
 		cmd.GetFormatter(),
+		output,
+	)
+
+	hasDifferences, err := controller.Run()
+	if err != nil {
+		fmt.Fprintf(stderr, "%v\n", err)
+
+		return exitTrouble
+	}
+
+	if hasDifferences {
+		return exitDifferent
+	}
+
+	return exitSame
+}
+
+func runDirectories(cmd *diffnest.Command, stdout, stderr io.Writer) int {
+	if !isDir(cmd.File1) || !isDir(cmd.File2) {
+		fmt.Fprintf(stderr, "Error: %v\n", diffnest.ErrDirectoryMismatch)
+
+		return exitTrouble
+	}
+	if err := cmd.ValidateDirectories(); err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+
+		return exitTrouble
+	}
+
+	controller := diffnest.NewDirController(
+		cmd.File1,
+		cmd.File2,
+		cmd.GetDirOptions(),
+		cmd.GetDiffOptions(),
+		cmd.GetFormatter(),
 		stdout,
 	)
 
@@ -68,16 +128,127 @@ func run(args []string, stdout, stderr io.Writer) int {
 	if err != nil {
 		fmt.Fprintf(stderr, "%v\n", err)
 
-		return 1
+		return exitTrouble
 	}
 
 	if hasDifferences {
-		return 1
+		return exitDifferent
+	}
+
+	return exitSame
+}
+
+func runApply(args []string, stdout, stderr io.Writer) int {
+	cmd := diffnest.NewApplyCommand("diffnest apply", flag.ContinueOnError)
+	cmd.SetOutput(stderr)
+
+	if err := cmd.Parse(args); err != nil {
+		if !errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+		}
+
+		return exitTrouble
+	}
+
+	if cmd.Help {
+		cmd.Usage(stderr)
+
+		return 0
+	}
+
+	docReader, err := openFile(cmd.File)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error opening document: %v\n", err)
+
+		return exitTrouble
+	}
+	defer closeReader(docReader)
+
+	patchReader, err := openFile(cmd.PatchFile)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error opening patch: %v\n", err)
+
+		return exitTrouble
+	}
+	defer closeReader(patchReader)
+
+	var patched bytes.Buffer
+	controller := diffnest.NewPatchController(
+		docReader,
+		patchReader,
+		cmd.GetFormat(),
+		cmd.GetPatchFormat(),
+		cmd.PatchType,
+		&patched,
+	)
+
+	if err := controller.Run(); err != nil {
+		fmt.Fprintf(stderr, "%v\n", err)
+
+		return exitTrouble
+	}
+
+	if cmd.Output == "" {
+		if _, err := patched.WriteTo(stdout); err != nil {
+			fmt.Fprintf(stderr, "Error writing output: %v\n", err)
+
+			return exitTrouble
+		}
+
+		return 0
+	}
+
+	if err := writeFileAtomic(cmd.Output, patched.Bytes()); err != nil {
+		fmt.Fprintf(stderr, "Error writing output: %v\n", err)
+
+		return exitTrouble
 	}
 
 	return 0
 }
 
+// writeFileAtomic replaces filename with data through a temporary file,
+// so the file is never left partially written.
+func writeFileAtomic(filename string, data []byte) error {
+	mode := os.FileMode(0o644)
+	if info, err := os.Stat(filename); err == nil {
+		mode = info.Mode().Perm()
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(filename), "."+filepath.Base(filename)+".*")
+	if err != nil {
+		return fmt.Errorf("create temporary file: %w", err)
+	}
+	defer os.Remove(tmp.Name()) //nolint:errcheck // Already renamed on success
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+
+		return fmt.Errorf("write temporary file: %w", err)
+	}
+	if err := tmp.Chmod(mode); err != nil {
+		tmp.Close()
+
+		return fmt.Errorf("set file mode: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("close temporary file: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), filename); err != nil {
+		return fmt.Errorf("replace %s: %w", filename, err)
+	}
+
+	return nil
+}
+
+// isDir reports whether filename names a directory.
+func isDir(filename string) bool {
+	info, err := os.Stat(filename)
+
+	return err == nil && info.IsDir()
+}
+
 func openFile(filename string) (io.ReadCloser, error) {
 	if filename == "-" {
 		return io.NopCloser(os.Stdin), nil
@@ -13,6 +13,7 @@ func TestCLI(t *testing.T) {
 	json1 := filepath.Join(tempDir, "test1.json")
 	json2 := filepath.Join(tempDir, "test2.json")
 	yaml1 := filepath.Join(tempDir, "test1.yaml")
+	yaml2 := filepath.Join(tempDir, "test2.yaml")
 
 	if err := os.WriteFile(json1, []byte(`{"name": "test", "value": 42, "enabled": true}`), 0o644); err != nil {
 		t.Fatal(err)
@@ -23,13 +24,37 @@ func TestCLI(t *testing.T) {
 	if err := os.WriteFile(yaml1, []byte("name: test\nvalue: 42\nenabled: true"), 0o644); err != nil {
 		t.Fatal(err)
 	}
+	if err := os.WriteFile(yaml2, []byte("name: test\nvalue: \"42\"\nenabled: \"true\""), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	merge1 := filepath.Join(tempDir, "merge1.json")
+	merge2 := filepath.Join(tempDir, "merge2.json")
+	if err := os.WriteFile(merge1, []byte(`{"a": 1}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(merge2, []byte(`{"a": null}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	dir1 := filepath.Join(tempDir, "dir1")
+	dir2 := filepath.Join(tempDir, "dir2")
+	for dir, content := range map[string]string{dir1: "name: test\n", dir2: `{"name": "test2"}`} {
+		if err := os.Mkdir(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "app.yaml"), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
 
 	tests := []struct {
-		name     string
-		args     []string
-		wantExit int
-		wantOut  string
-		wantErr  string
+		name      string
+		args      []string
+		wantExit  int
+		wantOut   string
+		wantErr   string
+		wantEmpty bool // No output on stdout
 	}{
 		{
 			name:     "Help flag",
@@ -40,19 +65,19 @@ func TestCLI(t *testing.T) {
 		{
 			name:     "Missing files",
 			args:     []string{},
-			wantExit: 1,
+			wantExit: 2,
 			wantErr:  "expected 2 files",
 		},
 		{
 			name:     "Only one file",
 			args:     []string{json1},
-			wantExit: 1,
+			wantExit: 2,
 			wantErr:  "expected 2 files",
 		},
 		{
 			name:     "Non-existent file",
 			args:     []string{json1, "nonexistent.json"},
-			wantExit: 1,
+			wantExit: 2,
 			wantErr:  "Error opening second file",
 		},
 		{
@@ -85,6 +110,67 @@ func TestCLI(t *testing.T) {
 			wantExit: 1,
 			wantOut:  "- name: test",
 		},
+		{
+			name:     "Color always",
+			args:     []string{"-color", "always", json1, json2},
+			wantExit: 1,
+			wantOut:  "\x1b[31m- name: test\x1b[0m",
+		},
+		{
+			name:     "Quoted values",
+			args:     []string{json1, yaml2},
+			wantExit: 1,
+			wantOut:  "- value: 42",
+		},
+		{
+			name:     "Type changes are no differences",
+			args:     []string{"-show-type-changes", json1, yaml2},
+			wantExit: 0,
+			wantOut:  "  value: 42  (type: number -> string)",
+		},
+		{
+			name:      "Quiet with differences",
+			args:      []string{"-q", json1, json2},
+			wantExit:  1,
+			wantEmpty: true,
+		},
+		{
+			name:      "Quiet without differences",
+			args:      []string{"--quiet", json1, yaml1},
+			wantExit:  0,
+			wantEmpty: true,
+		},
+		{
+			name:      "Quiet does not format",
+			args:      []string{"-q", "-format", "merge-patch", merge1, merge2},
+			wantExit:  1,
+			wantEmpty: true,
+		},
+		{
+			name:      "Quiet still reports errors",
+			args:      []string{"-q", json1, "nonexistent.json"},
+			wantExit:  2,
+			wantErr:   "Error opening second file",
+			wantEmpty: true,
+		},
+		{
+			name:     "Directories",
+			args:     []string{dir1, dir2},
+			wantExit: 1,
+			wantOut:  "1 file: 1 different, 0 same",
+		},
+		{
+			name:     "Directory and file",
+			args:     []string{dir1, json1},
+			wantExit: 2,
+			wantErr:  "cannot compare a directory with a file",
+		},
+		{
+			name:     "Directories with JSON patch format",
+			args:     []string{"-format", "json-patch", dir1, dir2},
+			wantExit: 2,
+			wantErr:  "directories can only be compared",
+		},
 		{
 			name:     "Force formats",
 			args:     []string{"-show-all", "-format1", "json", "-format2", "yaml", json1, yaml1},
@@ -102,6 +188,10 @@ func TestCLI(t *testing.T) {
 				t.Errorf("Exit code = %d, want %d", exitCode, tt.wantExit)
 			}
 
+			if tt.wantEmpty && stdout.Len() > 0 {
+				t.Errorf("stdout = %q, want no output", stdout.String())
+			}
+
 			if tt.wantOut != "" && !strings.Contains(stdout.String(), tt.wantOut) {
 				t.Errorf("stdout missing expected string %q\nGot:\n%s", tt.wantOut, stdout.String())
 			}
@@ -112,3 +202,47 @@ func TestCLI(t *testing.T) {
 		})
 	}
 }
+
+func TestCLI_Apply(t *testing.T) {
+	tempDir := t.TempDir()
+	doc := filepath.Join(tempDir, "config.yaml")
+	patch := filepath.Join(tempDir, "patch.json")
+	badPatch := filepath.Join(tempDir, "bad.json")
+
+	original := "name: app\nreplicas: 1\n"
+	if err := os.WriteFile(doc, []byte(original), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(patch, []byte(`[{"op": "replace", "path": "/replicas", "value": 2}]`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(badPatch, []byte(`[{"op": "test", "path": "/replicas", "value": 5}]`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if exitCode := run([]string{"apply", doc, patch}, &stdout, &stderr); exitCode != 0 {
+		t.Fatalf("Exit code = %d, stderr:\n%s", exitCode, stderr.String())
+	}
+	if want := "name: app\nreplicas: 2\n"; stdout.String() != want {
+		t.Errorf("stdout = %q, want %q", stdout.String(), want)
+	}
+
+	stderr.Reset()
+	if exitCode := run([]string{"apply", "-o", doc, doc, badPatch}, &stdout, &stderr); exitCode != 2 {
+		t.Errorf("Exit code = %d, want 2", exitCode)
+	}
+	if !strings.Contains(stderr.String(), `operation [0] (test "/replicas")`) {
+		t.Errorf("stderr missing failing operation\nGot:\n%s", stderr.String())
+	}
+	if content, _ := os.ReadFile(doc); string(content) != original {
+		t.Errorf("document modified by failing patch: %q", content)
+	}
+
+	if exitCode := run([]string{"apply", "-o", doc, doc, patch}, &stdout, &stderr); exitCode != 0 {
+		t.Fatalf("Exit code = %d, stderr:\n%s", exitCode, stderr.String())
+	}
+	if content, _ := os.ReadFile(doc); string(content) != "name: app\nreplicas: 2\n" {
+		t.Errorf("document not patched in place: %q", content)
+	}
+}